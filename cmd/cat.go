@@ -2,33 +2,47 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
+	"os"
 
-	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var catCmd = &cobra.Command{
-	Use:   "cat [id]",
+	Use:   "cat [id] [filename]",
 	Short: "Print snippet content to stdout",
-	Long:  `Display the content of a snippet by its ID. Perfect for piping to other commands.`,
-	Args:  cobra.ExactArgs(1),
+	Long:  `Display the content of a snippet by its ID, slug, UUID or title. Perfect for piping to other commands. For a multi-file snippet, name a file to print just that file.`,
+	Args:  cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		snippet, err := resolveSnippet(args[0])
 		if err != nil {
-			fmt.Println(ui.RenderError("Invalid snippet ID. Please provide a valid number."))
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		id := snippet.ID
+
+		if _, err := decryptSnippet(snippet); err != nil {
+			fmt.Println(ui.RenderError("Error decrypting snippet: " + err.Error()))
 			return
 		}
 
-		snippet, err := storage.GetSnippetByID(id)
+		var name string
+		if len(args) > 1 {
+			name = args[1]
+		}
+		content, err := snippetText(snippet, name)
 		if err != nil {
 			fmt.Println(ui.RenderError(err.Error()))
 			return
 		}
 
 		// Just print the content - no extra formatting for piping
-		fmt.Print(snippet.Content)
+		fmt.Print(content)
+
+		// Keep stdout clean for pipes; usage tracking problems go to stderr
+		if err := store.MarkUsed(id); err != nil {
+			fmt.Fprintln(os.Stderr, ui.RenderWarning("Could not record snippet usage: "+err.Error()))
+		}
 	},
 }
 
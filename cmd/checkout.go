@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var forceCheckout bool
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout [id] [dir]",
+	Short: "Write a snippet's files to a directory",
+	Long: `Write every file of a multi-file snippet into a directory, creating it if needed.
+A single-content snippet is written to one file named after its title. Existing files are only overwritten with --force.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[1]
+
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		id := snippet.ID
+
+		if _, err := decryptSnippet(snippet); err != nil {
+			fmt.Println(ui.RenderError("Error decrypting snippet: " + err.Error()))
+			return
+		}
+
+		files := snippet.Files
+		if len(files) == 0 {
+			files = []models.File{{Name: titleFileName(snippet.Title), Content: snippet.Content}}
+		}
+		if err := storage.ValidateFiles(files); err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		// Check everything up front so a refusal never leaves half a checkout
+		if !forceCheckout {
+			for _, file := range files {
+				path := filepath.Join(dir, file.Name)
+				if _, err := os.Stat(path); err == nil {
+					fmt.Println(ui.RenderError(fmt.Sprintf("%s already exists. Use --force to overwrite it.", path)))
+					return
+				}
+			}
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Println(ui.RenderError(fmt.Sprintf("Error creating directory %s: %s", dir, err.Error())))
+			return
+		}
+
+		for _, file := range files {
+			path := filepath.Join(dir, file.Name)
+			if err := os.WriteFile(path, []byte(file.Content), 0644); err != nil {
+				fmt.Println(ui.RenderError("Error writing file: " + err.Error()))
+				return
+			}
+			fmt.Printf("  %s %s\n", ui.IconSuccess, path)
+		}
+
+		fmt.Println()
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Checked out %d file(s) of '%s' to %s", len(files), snippet.Title, dir)))
+
+		if err := store.MarkUsed(id); err != nil {
+			fmt.Println(ui.RenderWarning("Could not record snippet usage: " + err.Error()))
+		}
+	},
+}
+
+// unsafeFileChars matches runs of characters that don't belong in a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// titleFileName derives a file name from a snippet title
+func titleFileName(title string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(title), "-"), "-.")
+	if name == "" {
+		name = "snippet"
+	}
+	if filepath.Ext(name) == "" {
+		name += ".txt"
+	}
+	return name
+}
+
+// snippetText returns the text of a snippet, or of one of its files when
+// name is given. A multi-file snippet without a name yields every file, each
+// under a header.
+func snippetText(snippet *models.Snippet, name string) (string, error) {
+	if name == "" {
+		if len(snippet.Files) > 0 {
+			return storage.JoinFiles(snippet.Files), nil
+		}
+		return snippet.Content, nil
+	}
+
+	file, err := snippetFile(snippet, name)
+	if err != nil {
+		return "", err
+	}
+	return file.Content, nil
+}
+
+// snippetFile finds a file of a multi-file snippet by name
+func snippetFile(snippet *models.Snippet, name string) (*models.File, error) {
+	if len(snippet.Files) == 0 {
+		return nil, fmt.Errorf("snippet %d has no files; it holds a single piece of content", snippet.ID)
+	}
+
+	for i := range snippet.Files {
+		if snippet.Files[i].Name == name {
+			return &snippet.Files[i], nil
+		}
+	}
+	return nil, fmt.Errorf("snippet %d has no file '%s'. Its files are: %s", snippet.ID, name, fileNames(snippet))
+}
+
+// fileNames lists the files of a multi-file snippet for messages
+func fileNames(snippet *models.Snippet) string {
+	var names []string
+	for _, file := range snippet.Files {
+		names = append(names, file.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	checkoutCmd.Flags().BoolVarP(&forceCheckout, "force", "f", false, "Overwrite existing files")
+	rootCmd.AddCommand(checkoutCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var collectionsCmd = &cobra.Command{
+	Use:     "collections",
+	Aliases: []string{"tree"},
+	Short:   "Show collections as a tree",
+	Long:    `Show the collections of the library as a tree, with the number of snippets in each. File snippets with 'snip save --in' or 'snip mv', and list one collection with 'snip list --in'.`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snippets, err := store.List(storage.ListOptions{})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error listing snippets: " + err.Error()))
+			return
+		}
+
+		counts := map[string]int{}
+		for _, snippet := range snippets {
+			counts[snippet.Collection]++
+		}
+
+		fmt.Println(ui.RenderTitle(ui.IconFolder + " Collections" + libraryLabel(false)))
+		fmt.Println()
+		fmt.Println(ui.RenderCollectionTree(counts))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(collectionsCmd)
+}
@@ -2,33 +2,41 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/atotto/clipboard"
-	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var copyCmd = &cobra.Command{
-	Use:   "copy [id]",
+	Use:   "copy [id] [filename]",
 	Short: "Copy snippet content to clipboard",
-	Long:  `Copy the content of a snippet to your system clipboard by its ID.`,
-	Args:  cobra.ExactArgs(1),
+	Long:  `Copy the content of a snippet to your system clipboard by its ID, slug, UUID or title. For a multi-file snippet, name a file to copy just that file.`,
+	Args:  cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		snippet, err := resolveSnippet(args[0])
 		if err != nil {
-			fmt.Println(ui.RenderError("Invalid snippet ID. Please provide a valid number."))
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		id := snippet.ID
+
+		if _, err := decryptSnippet(snippet); err != nil {
+			fmt.Println(ui.RenderError("Error decrypting snippet: " + err.Error()))
 			return
 		}
 
-		snippet, err := storage.GetSnippetByID(id)
+		var name string
+		if len(args) > 1 {
+			name = args[1]
+		}
+		content, err := snippetText(snippet, name)
 		if err != nil {
 			fmt.Println(ui.RenderError(err.Error()))
 			return
 		}
 
-		err = clipboard.WriteAll(snippet.Content)
+		err = clipboard.WriteAll(content)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error copying to clipboard: " + err.Error()))
 			return
@@ -36,6 +44,10 @@ var copyCmd = &cobra.Command{
 
 		successMsg := fmt.Sprintf("%s Copied snippet '%s' to clipboard!", ui.IconCopy, snippet.Title)
 		fmt.Println(ui.RenderSuccess(successMsg))
+
+		if err := store.MarkUsed(id); err != nil {
+			fmt.Println(ui.RenderWarning("Could not record snippet usage: " + err.Error()))
+		}
 	},
 }
 
@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/lubasinkal/snip/internal/backup"
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateStatus bool
+	repairDB      bool
+	forceBackup   bool
+	forceRestore  bool
+	restoreLatest bool
+	keepBackups   int
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the snippet database",
+	Long:  `Maintenance commands for the SQLite database that stores your snippets.`,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long:  `Bring the database schema up to date. Use --status to list every migration and whether it has been applied.`,
+	// Other commands migrate the database when they open it; this one shows
+	// what is pending first
+	Annotations: map[string]string{noMigrateAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateStatus {
+			showMigrationStatus()
+			return
+		}
+
+		migrator, ok := store.(storage.Migrator)
+		if !ok {
+			fmt.Println(ui.RenderError("This storage backend does not use schema migrations"))
+			return
+		}
+
+		applied, err := migrator.Migrate()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error applying migrations: " + err.Error()))
+			return
+		}
+
+		version, err := migrator.SchemaVersion()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading schema version: " + err.Error()))
+			return
+		}
+
+		if len(applied) == 0 {
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("%s Database schema is up to date (version %d)", ui.IconDatabase, version)))
+			return
+		}
+
+		for _, m := range applied {
+			fmt.Printf("  %s Applied %d: %s\n", ui.IconSuccess, m.Version, m.Name)
+		}
+		fmt.Println()
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Migrated database to schema version %d", version)))
+	},
+}
+
+// showMigrationStatus prints a table of every known migration
+func showMigrationStatus() {
+	migrator, ok := store.(storage.Migrator)
+	if !ok {
+		fmt.Println(ui.RenderError("This storage backend does not use schema migrations"))
+		return
+	}
+
+	infos, err := migrator.MigrationStatus()
+	if err != nil {
+		fmt.Println(ui.RenderError("Error reading migration status: " + err.Error()))
+		return
+	}
+
+	fmt.Println(ui.RenderTitle(ui.IconDatabase + " Schema Migrations"))
+	fmt.Println()
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(ui.Border)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return lipgloss.NewStyle().
+					Foreground(ui.Primary).
+					Bold(true).
+					Align(lipgloss.Center).
+					Padding(0, 1)
+			}
+			return lipgloss.NewStyle().
+				Foreground(ui.Text).
+				Padding(0, 1)
+		}).
+		Headers("Version", "Name", "Status", "Applied")
+
+	pending := 0
+	for _, m := range infos {
+		status := ui.SuccessStyle.Render("applied")
+		appliedAt := ui.LocalTime(m.AppliedAt).Format("Jan 2, 2006 15:04")
+		if !m.Applied {
+			status = ui.WarningStyle.Render("pending")
+			appliedAt = "-"
+			pending++
+		}
+
+		t.Row(fmt.Sprintf("%d", m.Version), m.Name, status, appliedAt)
+	}
+
+	fmt.Println(t.Render())
+	fmt.Println()
+
+	if pending > 0 {
+		fmt.Println(ui.RenderWarning(fmt.Sprintf("%d pending migration(s). Run 'snip db migrate' to apply them.", pending)))
+	} else {
+		fmt.Println(ui.RenderSuccess("All migrations applied"))
+	}
+}
+
+var dbInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the database location, size and contents",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		maintainer, ok := maintainerStore()
+		if !ok {
+			return
+		}
+
+		info, err := maintainer.Info()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading database info: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderTitle(ui.IconDatabase + " Database"))
+		fmt.Println()
+		fmt.Println(ui.RenderBox(fmt.Sprintf(`Library:        %s
+Path:           %s
+Size:           %s (%s reclaimable by vacuum)
+Schema version: %d
+Snippets:       %d
+In trash:       %d
+Tags:           %d
+Files:          %d
+Revisions:      %d`,
+			library.Name,
+			info.Path,
+			formatBytes(info.Size),
+			formatBytes(int64(info.FreePages)*int64(info.PageSize)),
+			info.SchemaVersion,
+			info.Snippets,
+			info.Trashed,
+			info.Tags,
+			info.Files,
+			info.Revisions)))
+	},
+}
+
+var dbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the database for corruption and inconsistencies",
+	Long: `Run SQLite's integrity check and verify that tags and the search index match the snippets.
+Use --repair to rebuild the search index and drop unused tags when they are out of step.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		maintainer, ok := maintainerStore()
+		if !ok {
+			return
+		}
+
+		result, err := maintainer.Check()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error checking database: " + err.Error()))
+			return
+		}
+
+		if result.OK() {
+			fmt.Println(ui.RenderSuccess("Database is healthy"))
+			return
+		}
+
+		for _, problem := range result.Problems {
+			fmt.Printf("  %s %s\n", ui.IconError, problem)
+		}
+		for _, problem := range result.Repairable {
+			fmt.Printf("  %s %s\n", ui.IconWarning, problem)
+		}
+		fmt.Println()
+
+		if len(result.Repairable) > 0 {
+			if !repairDB {
+				fmt.Println(ui.RenderWarning("Run 'snip db check --repair' to rebuild the search index and remove unused tags."))
+			} else if err := maintainer.Repair(); err != nil {
+				fmt.Println(ui.RenderError("Error repairing database: " + err.Error()))
+				return
+			} else {
+				fmt.Println(ui.RenderSuccess("Rebuilt the search index and removed unused tags"))
+			}
+		}
+
+		if len(result.Problems) > 0 {
+			fmt.Println(ui.RenderError("The database file is damaged. Restore a backup with 'snip db restore <path>'."))
+		}
+	},
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Compact the database file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		maintainer, ok := maintainerStore()
+		if !ok {
+			return
+		}
+
+		// Count the write-ahead log too, which vacuum empties
+		before := fileSize(maintainer.Path()) + fileSize(maintainer.Path()+"-wal")
+		if err := maintainer.Vacuum(); err != nil {
+			fmt.Println(ui.RenderError("Error vacuuming database: " + err.Error()))
+			return
+		}
+		after := fileSize(maintainer.Path()) + fileSize(maintainer.Path()+"-wal")
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Vacuumed database: %s → %s", formatBytes(before), formatBytes(after))))
+	},
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup <path>",
+	Short: "Copy the database to a file",
+	Long: `Write a consistent copy of the database to a file using SQLite's online backup API.
+It is safe to run while other snip commands are using the database.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		maintainer, ok := maintainerStore()
+		if !ok {
+			return
+		}
+
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		if path == maintainer.Path() {
+			fmt.Println(ui.RenderError("The backup cannot replace the database itself"))
+			return
+		}
+		if _, err := os.Stat(path); err == nil && !forceBackup {
+			fmt.Println(ui.RenderError(fmt.Sprintf("%s already exists. Use --force to overwrite it.", path)))
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			fmt.Println(ui.RenderError("Error creating backup directory: " + err.Error()))
+			return
+		}
+
+		if err := maintainer.Backup(path); err != nil {
+			fmt.Println(ui.RenderError("Error backing up database: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Backed up database to %s (%s)", path, formatBytes(fileSize(path)))))
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore [path]",
+	Short: "Replace the database with a backup",
+	Long: `Replace every snippet in the current library with the contents of a backup made by 'snip db backup'.
+Use --latest instead of a path to roll back to the newest automatic backup. The library is backed up
+first, so running 'snip db restore --latest' again undoes the restore.
+Backups from older versions of snip are migrated to the current schema.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		maintainer, ok := maintainerStore()
+		if !ok {
+			return
+		}
+
+		var path string
+		switch {
+		case restoreLatest && len(args) > 0:
+			fmt.Println(ui.RenderError("Give either a backup path or --latest, not both"))
+			return
+		case restoreLatest:
+			latest, err := backup.Latest(library.Name)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			path = latest.Path
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("Latest backup: %s, taken %s before '%s'",
+				filepath.Base(path), ui.FormatTimeAgo(latest.CreatedAt), latest.Reason)))
+		case len(args) == 1:
+			path = args[0]
+		default:
+			fmt.Println(ui.RenderError("Give the path of a backup, or --latest to use the newest automatic backup"))
+			return
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			fmt.Println(ui.RenderError(fmt.Sprintf("Backup not found: %s", path)))
+			return
+		}
+
+		if !forceRestore {
+			fmt.Printf("%s Replace every snippet in library '%s' with the backup %s? [y/N]: ", ui.IconWarning, library.Name, path)
+
+			reader := bufio.NewReader(os.Stdin)
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				fmt.Println(ui.RenderError("Error reading input: " + err.Error()))
+				return
+			}
+
+			response = strings.ToLower(strings.TrimSpace(response))
+			if response != "y" && response != "yes" {
+				fmt.Println(ui.RenderInfo("Restore cancelled."))
+				return
+			}
+		}
+
+		// Taken only now, so --latest above never picks the current state
+		snapshot, err := takeSnapshot("restore")
+		if err != nil {
+			fmt.Println(ui.RenderError("Error backing up the current database: " + err.Error()))
+			return
+		}
+
+		if err := maintainer.RestoreFrom(path); err != nil {
+			fmt.Println(ui.RenderError("Error restoring database: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Restored database from %s", path)))
+		if snapshot != nil {
+			fmt.Println(ui.RenderInfo("The previous contents were saved to " + snapshot.Path))
+		}
+	},
+}
+
+var dbBackupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List automatic backups",
+	Long: `List the automatic backups of the current library. snip backs a library up before delete, edit,
+import and trash purge, and at most once a day otherwise. Use --keep to change how many backups are
+kept per library; --keep 0 turns automatic backups off.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error loading config: " + err.Error()))
+			return
+		}
+
+		if cmd.Flags().Changed("keep") {
+			if err := cfg.SetKeepBackups(keepBackups); err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if err := cfg.Save(); err != nil {
+				fmt.Println(ui.RenderError("Error saving config: " + err.Error()))
+				return
+			}
+
+			if keepBackups == 0 {
+				fmt.Println(ui.RenderSuccess("Automatic backups turned off. Existing backups are kept in " + backup.Dir()))
+				return
+			}
+
+			for _, lib := range cfg.AllLibraries() {
+				if err := backup.Prune(lib.Name, keepBackups); err != nil {
+					fmt.Println(ui.RenderError("Error removing old backups: " + err.Error()))
+					return
+				}
+			}
+			fmt.Println(ui.RenderSuccess(fmt.Sprintf("Keeping the last %d automatic backup(s) of each library", keepBackups)))
+			return
+		}
+
+		snapshots, err := backup.List(library.Name)
+		if err != nil {
+			fmt.Println(ui.RenderError("Error listing backups: " + err.Error()))
+			return
+		}
+
+		if len(snapshots) == 0 {
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("Library '%s' has no automatic backups yet", library.Name)))
+			return
+		}
+
+		fmt.Println(ui.RenderTitle(fmt.Sprintf("%s Automatic Backups (%s)", ui.IconDatabase, library.Name)))
+		fmt.Println()
+
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(ui.Border)).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return lipgloss.NewStyle().
+						Foreground(ui.Primary).
+						Bold(true).
+						Align(lipgloss.Center).
+						Padding(0, 1)
+				}
+				return lipgloss.NewStyle().
+					Foreground(ui.Text).
+					Padding(0, 1)
+			}).
+			Headers("Taken", "Before", "Size", "File")
+
+		// Newest first, the order they would be rolled back to
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			s := snapshots[i]
+			t.Row(ui.LocalTime(s.CreatedAt).Format("Jan 2, 2006 15:04"), s.Reason, formatBytes(s.Size), filepath.Base(s.Path))
+		}
+
+		fmt.Println(t.Render())
+		fmt.Println()
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("Keeping the last %d in %s. Roll back with 'snip db restore --latest'.", cfg.KeepBackups(), backup.Dir())))
+	},
+}
+
+// maintainerStore returns the current store if it supports maintenance,
+// printing an error otherwise
+func maintainerStore() (storage.Maintainer, bool) {
+	maintainer, ok := store.(storage.Maintainer)
+	if !ok {
+		fmt.Println(ui.RenderError("This storage backend does not support database maintenance"))
+	}
+	return maintainer, ok
+}
+
+// fileSize returns the size of the file at path, or 0 if it can't be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// formatBytes renders a byte count in the largest fitting binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	dbMigrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "Show applied and pending migrations without changing anything")
+	dbCheckCmd.Flags().BoolVar(&repairDB, "repair", false, "Rebuild the search index and remove unused tags if needed")
+	dbBackupCmd.Flags().BoolVarP(&forceBackup, "force", "f", false, "Overwrite an existing file")
+	dbRestoreCmd.Flags().BoolVarP(&forceRestore, "force", "f", false, "Skip confirmation prompt")
+	dbRestoreCmd.Flags().BoolVar(&restoreLatest, "latest", false, "Restore the newest automatic backup")
+	dbBackupsCmd.Flags().IntVar(&keepBackups, "keep", config.DefaultBackupKeep, "Set how many automatic backups to keep per library (0 turns them off)")
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbInfoCmd)
+	dbCmd.AddCommand(dbCheckCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbBackupsCmd)
+	rootCmd.AddCommand(dbCmd)
+}
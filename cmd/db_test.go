@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// pendingMigrations counts the migrations not yet applied to the database
+// at path
+func pendingMigrations(t *testing.T, path string) int {
+	t.Helper()
+	migrator, err := storage.OpenSQLiteUnmigrated(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+
+	infos, err := migrator.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := 0
+	for _, m := range infos {
+		if !m.Applied {
+			pending++
+		}
+	}
+	return pending
+}
+
+func TestDBMigrateFromVersion1(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snippets.db")
+	t.Setenv("HOME", dir)
+	t.Setenv("SNIP_DB_PATH", path)
+
+	// A database as the first release of the schema left it
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE snippets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT,
+			tags TEXT,
+			content TEXT,
+			created_at DATETIME
+		)`,
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TEXT NOT NULL)`,
+		`INSERT INTO schema_version VALUES (1, 'create snippets table', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO snippets (title, tags, content, created_at)
+			VALUES ('Docker prune', 'docker,cleanup', 'docker system prune -f', '2024-01-02 10:00:00')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	pending := pendingMigrations(t, path)
+	if pending == 0 {
+		t.Fatal("no pending migrations after version 1")
+	}
+
+	out := runCommand(t, "", "db", "migrate", "--status")
+	for _, want := range []string{"pending", fmt.Sprintf("%d pending migration(s)", pending)} {
+		if !strings.Contains(out, want) {
+			t.Errorf("migrate --status is missing %q:\n%s", want, out)
+		}
+	}
+	if got := pendingMigrations(t, path); got != pending {
+		t.Errorf("migrate --status applied migrations: %d pending, want %d", got, pending)
+	}
+
+	// Flags keep their values between runs, so turn --status off again
+	out = runCommand(t, "", "db", "migrate", "--status=false")
+	for _, want := range []string{"Applied 2: normalize tags", "Migrated database to schema version"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("migrate is missing %q:\n%s", want, out)
+		}
+	}
+	if got := pendingMigrations(t, path); got != 0 {
+		t.Errorf("%d migrations still pending after migrate", got)
+	}
+
+	out = runCommand(t, "", "db", "migrate", "--status")
+	if !strings.Contains(out, "All migrations applied") {
+		t.Errorf("migrate --status after migrating:\n%s", out)
+	}
+	if out := runCommand(t, "", "cat", "docker-prune"); out != "docker system prune -f" {
+		t.Errorf("cat after migrating printed %q", out)
+	}
+}
@@ -4,10 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 
-	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -15,23 +13,19 @@ import (
 var forceDelete bool
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete [id]",
-	Short: "Delete a snippet",
-	Long:  `Remove a snippet from your collection by its ID. Use --force to skip confirmation.`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "delete [id]",
+	Short:       "Delete a snippet",
+	Long:        `Move a snippet to the trash by its ID, slug, UUID or title. Use --force to skip confirmation. Deleted snippets can be brought back with 'snip restore' until the trash is purged.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			fmt.Println(ui.RenderError("Invalid snippet ID. Please provide a valid number."))
-			return
-		}
-
 		// First, get the snippet to show what we're deleting
-		snippet, err := storage.GetSnippetByID(id)
+		snippet, err := resolveSnippet(args[0])
 		if err != nil {
 			fmt.Println(ui.RenderError(err.Error()))
 			return
 		}
+		id := snippet.ID
 
 		// Show confirmation unless --force is used
 		if !forceDelete {
@@ -58,14 +52,16 @@ var deleteCmd = &cobra.Command{
 		}
 
 		// Delete the snippet
-		err = storage.DeleteSnippet(id)
+		backupBefore("delete")
+		err = store.Delete(id)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error deleting snippet: " + err.Error()))
 			return
 		}
 
-		successMsg := fmt.Sprintf("Deleted snippet '%s' (ID: %d)", snippet.Title, id)
+		successMsg := fmt.Sprintf("Moved snippet '%s' to the trash (ID: %d)", snippet.Title, id)
 		fmt.Println(ui.RenderSuccess(successMsg))
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("Changed your mind? Run 'snip restore %d'", id)))
 	},
 }
 
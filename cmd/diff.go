@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/diff"
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/secret"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var diffContext int
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [id|slug|title] [rev] [rev]",
+	Short: "Compare revisions of a snippet",
+	Long: `Show a unified diff between two revisions of a snippet.
+
+With only a snippet, the latest change is shown (previous revision against the current one).
+With one revision, that revision is compared against the current one.
+With two revisions, the first is compared against the second.`,
+	Args: cobra.RangeArgs(1, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		var numbers []int
+		for _, arg := range args[1:] {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				fmt.Println(ui.RenderError(fmt.Sprintf("Invalid revision '%s'. Please provide revision numbers.", arg)))
+				return
+			}
+			numbers = append(numbers, n)
+		}
+
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		id := snippet.ID
+
+		revisions, err := store.History(id)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		if len(revisions) == 0 {
+			fmt.Println(ui.RenderInfo("No revisions recorded for this snippet."))
+			return
+		}
+
+		current := revisions[len(revisions)-1].Number
+		var from, to int
+		switch len(numbers) {
+		case 0:
+			if len(revisions) < 2 {
+				fmt.Println(ui.RenderInfo("This snippet has only one revision, so there is nothing to compare."))
+				return
+			}
+			from, to = revisions[len(revisions)-2].Number, current
+		case 1:
+			from, to = numbers[0], current
+		default:
+			from, to = numbers[0], numbers[1]
+		}
+
+		fromRev, err := store.Revision(id, from)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		toRev, err := store.Revision(id, to)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		// Revisions of encrypted snippets are compared as plaintext
+		if revisionEncrypted(fromRev) || revisionEncrypted(toRev) {
+			passphrase, err := readPassphrase(fmt.Sprintf("Passphrase for snippet %d: ", id), false)
+			if err != nil {
+				fmt.Println(ui.RenderError("Error reading passphrase: " + err.Error()))
+				return
+			}
+			for _, rev := range []*models.Revision{fromRev, toRev} {
+				rev.Content, rev.Files, err = decryptContent(rev.Content, rev.Files, passphrase)
+				if err != nil {
+					fmt.Println(ui.RenderError(fmt.Sprintf("Error decrypting revision %d: %s", rev.Number, err.Error())))
+					return
+				}
+			}
+		}
+
+		fmt.Println(ui.RenderTitle(fmt.Sprintf("%s Snippet %d: revision %d → %d", ui.IconEdit, id, from, to)))
+
+		// Title and tags aren't part of the content diff, so call them out separately
+		if fromRev.Title != toRev.Title {
+			fmt.Printf("  Title: %s → %s\n", fromRev.Title, toRev.Title)
+		}
+		if strings.Join(fromRev.Tags, ",") != strings.Join(toRev.Tags, ",") {
+			fmt.Printf("  Tags: %s → %s\n", formatRevisionTags(fromRev), formatRevisionTags(toRev))
+		}
+
+		hunks := diff.Hunks(diff.Lines(revisionText(fromRev), revisionText(toRev)), diffContext)
+		if len(hunks) == 0 {
+			fmt.Println(ui.RenderInfo("Content is identical."))
+			return
+		}
+
+		fmt.Println()
+		fmt.Print(ui.RenderDiff(revisionLabel(fromRev, current), revisionLabel(toRev, current), hunks))
+	},
+}
+
+// revisionEncrypted reports whether any part of a revision is ciphertext
+func revisionEncrypted(r *models.Revision) bool {
+	if secret.IsEncrypted(r.Content) {
+		return true
+	}
+	for _, file := range r.Files {
+		if secret.IsEncrypted(file.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionText is the text of a revision that diffs compare. Files of a
+// multi-file snippet are compared together, each under its own header.
+func revisionText(r *models.Revision) string {
+	if len(r.Files) > 0 {
+		return storage.JoinFiles(r.Files)
+	}
+	return r.Content
+}
+
+// revisionLabel names a revision for the diff header
+func revisionLabel(r *models.Revision, current int) string {
+	label := fmt.Sprintf("revision %d (%s)", r.Number, ui.LocalTime(r.CreatedAt).Format("Jan 2, 2006 15:04"))
+	if r.Number == current {
+		label += " current"
+	}
+	return label
+}
+
+// formatRevisionTags renders a revision's tags for a one-line summary
+func formatRevisionTags(r *models.Revision) string {
+	if len(r.Tags) == 0 {
+		return "(none)"
+	}
+	var formattedTags []string
+	for _, tag := range r.Tags {
+		formattedTags = append(formattedTags, ui.RenderTag(tag))
+	}
+	return strings.Join(formattedTags, " ")
+}
+
+func init() {
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 3, "Number of unchanged lines to show around each change")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -5,29 +5,60 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"strconv"
 	"strings"
 
-	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var editCmd = &cobra.Command{
-	Use:   "edit [id]",
-	Short: "Edit a snippet in your default editor",
-	Long:  `Open a snippet in your default editor ($EDITOR) and save changes back to the database.`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "edit [id] [filename]",
+	Short:       "Edit a snippet in your default editor",
+	Long:        `Open a snippet, given by its ID, slug, UUID or title, in your default editor ($EDITOR) and save changes back to the database. Multi-file snippets are edited one file at a time; name the file to edit.`,
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
+		// Get the snippet
+		snippet, err := resolveSnippet(args[0])
 		if err != nil {
-			fmt.Println(ui.RenderError("Invalid snippet ID. Please provide a valid number."))
+			fmt.Println(ui.RenderError(err.Error()))
 			return
 		}
+		id := snippet.ID
 
-		// Get the snippet
-		snippet, err := storage.GetSnippetByID(id)
+		// Encrypted snippets are edited as plaintext and sealed again with the
+		// same passphrase
+		passphrase, err := decryptSnippet(snippet)
 		if err != nil {
+			fmt.Println(ui.RenderError("Error decrypting snippet: " + err.Error()))
+			return
+		}
+
+		// Pick the text to edit: the content, or one file of a multi-file snippet
+		target := &snippet.Content
+		tmpPattern := fmt.Sprintf("snip_%d_*.txt", id)
+		if len(snippet.Files) > 0 {
+			var name string
+			switch {
+			case len(args) > 1:
+				name = args[1]
+			case len(snippet.Files) == 1:
+				name = snippet.Files[0].Name
+			default:
+				fmt.Println(ui.RenderError(fmt.Sprintf("Snippet %d has several files. Name the one to edit: %s", id, fileNames(snippet))))
+				return
+			}
+
+			file, err := snippetFile(snippet, name)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			target = &file.Content
+			// Keep the extension so the editor can highlight the file
+			tmpPattern = fmt.Sprintf("snip_%d_*_%s", id, file.Name)
+		} else if len(args) > 1 {
+			_, err := snippetFile(snippet, args[1])
 			fmt.Println(ui.RenderError(err.Error()))
 			return
 		}
@@ -51,7 +82,7 @@ var editCmd = &cobra.Command{
 		}
 
 		// Create temporary file
-		tmpFile, err := ioutil.TempFile("", fmt.Sprintf("snip_%d_*.txt", id))
+		tmpFile, err := ioutil.TempFile("", tmpPattern)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error creating temporary file: " + err.Error()))
 			return
@@ -59,7 +90,7 @@ var editCmd = &cobra.Command{
 		defer os.Remove(tmpFile.Name())
 
 		// Write current content to temp file
-		_, err = tmpFile.WriteString(snippet.Content)
+		_, err = tmpFile.WriteString(*target)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error writing to temporary file: " + err.Error()))
 			return
@@ -88,6 +119,10 @@ var editCmd = &cobra.Command{
 			return
 		}
 
+		if err := store.MarkUsed(id); err != nil {
+			fmt.Println(ui.RenderWarning("Could not record snippet usage: " + err.Error()))
+		}
+
 		// Read the modified content
 		modifiedContent, err := ioutil.ReadFile(tmpFile.Name())
 		if err != nil {
@@ -97,14 +132,26 @@ var editCmd = &cobra.Command{
 
 		// Check if content changed
 		newContent := string(modifiedContent)
-		if newContent == snippet.Content {
+		if newContent == *target {
 			fmt.Println(ui.RenderInfo("No changes made."))
 			return
 		}
 
 		// Update the snippet
-		snippet.Content = strings.TrimRight(newContent, "\n\r")
-		err = storage.UpdateSnippet(*snippet)
+		// Files are saved exactly as edited; plain content drops the
+		// trailing newline editors add
+		if len(snippet.Files) == 0 {
+			newContent = strings.TrimRight(newContent, "\n\r")
+		}
+		*target = newContent
+		if snippet.Encrypted {
+			if err := encryptSnippet(snippet, passphrase); err != nil {
+				fmt.Println(ui.RenderError("Error encrypting snippet: " + err.Error()))
+				return
+			}
+		}
+		backupBefore("edit")
+		err = store.Update(*snippet)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error saving changes: " + err.Error()))
 			return
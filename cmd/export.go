@@ -24,7 +24,7 @@ var exportCmd = &cobra.Command{
 	Short: "Export snippets to various formats",
 	Long:  `Export your snippets to JSON, Markdown, or plain text format for backup or sharing.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		snippets, err := storage.ListAllSnippets()
+		snippets, err := store.List(storage.ListOptions{})
 		if err != nil {
 			fmt.Println(ui.RenderError("Error loading snippets: " + err.Error()))
 			return
@@ -120,12 +120,16 @@ func exportToMarkdown(snippets []models.Snippet) (string, error) {
 	var content strings.Builder
 	
 	content.WriteString("# Code Snippets Export\n\n")
-	content.WriteString(fmt.Sprintf("Exported on: %s\n", time.Now().Format("January 2, 2006 at 3:04 PM")))
+	content.WriteString(fmt.Sprintf("Exported on: %s\n", ui.LocalTime(time.Now()).Format("January 2, 2006 at 3:04 PM")))
 	content.WriteString(fmt.Sprintf("Total snippets: %d\n\n", len(snippets)))
 	content.WriteString("---\n\n")
 
 	for i, snippet := range snippets {
 		content.WriteString(fmt.Sprintf("## %d. %s\n\n", snippet.ID, snippet.Title))
+
+		if snippet.Description != "" {
+			content.WriteString(snippet.Description + "\n\n")
+		}
 		
 		if len(snippet.Tags) > 0 {
 			content.WriteString("**Tags:** ")
@@ -138,11 +142,40 @@ func exportToMarkdown(snippets []models.Snippet) (string, error) {
 			content.WriteString("\n\n")
 		}
 		
-		content.WriteString(fmt.Sprintf("**Created:** %s\n\n", snippet.CreatedAt.Format("January 2, 2006")))
+		if snippet.Collection != "" {
+			content.WriteString(fmt.Sprintf("**Collection:** %s\n\n", snippet.Collection))
+		}
+
+		if len(snippet.Meta) > 0 {
+			content.WriteString(fmt.Sprintf("**Meta:** %s\n\n", ui.FormatMeta(snippet.Meta)))
+		}
+
+		if snippet.Language != "" {
+			content.WriteString(fmt.Sprintf("**Language:** %s\n\n", snippet.Language))
+		}
+
+		if snippet.SourceURL != "" {
+			content.WriteString(fmt.Sprintf("**Source:** <%s>\n\n", snippet.SourceURL))
+		}
+
+		content.WriteString(fmt.Sprintf("**Created:** %s\n\n", ui.LocalTime(snippet.CreatedAt).Format("January 2, 2006")))
+
+		if snippet.Encrypted {
+			content.WriteString("**Encrypted:** yes, decrypt with `snip cat` after importing\n\n")
+		}
 		
-		content.WriteString("```\n")
-		content.WriteString(snippet.Content)
-		content.WriteString("\n```\n\n")
+		if len(snippet.Files) > 0 {
+			for _, file := range snippet.Files {
+				content.WriteString(fmt.Sprintf("### %s\n\n", file.Name))
+				content.WriteString("```" + snippet.Language + "\n")
+				content.WriteString(file.Content)
+				content.WriteString("\n```\n\n")
+			}
+		} else {
+			content.WriteString("```" + snippet.Language + "\n")
+			content.WriteString(snippet.Content)
+			content.WriteString("\n```\n\n")
+		}
 		
 		if i < len(snippets)-1 {
 			content.WriteString("---\n\n")
@@ -157,7 +190,7 @@ func exportToText(snippets []models.Snippet) (string, error) {
 	
 	content.WriteString("CODE SNIPPETS EXPORT\n")
 	content.WriteString("===================\n\n")
-	content.WriteString(fmt.Sprintf("Exported on: %s\n", time.Now().Format("January 2, 2006 at 3:04 PM")))
+	content.WriteString(fmt.Sprintf("Exported on: %s\n", ui.LocalTime(time.Now()).Format("January 2, 2006 at 3:04 PM")))
 	content.WriteString(fmt.Sprintf("Total snippets: %d\n\n", len(snippets)))
 
 	for i, snippet := range snippets {
@@ -167,10 +200,34 @@ func exportToText(snippets []models.Snippet) (string, error) {
 		if len(snippet.Tags) > 0 {
 			content.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(snippet.Tags, ", ")))
 		}
+		if snippet.Collection != "" {
+			content.WriteString(fmt.Sprintf("Collection: %s\n", snippet.Collection))
+		}
+		if len(snippet.Meta) > 0 {
+			content.WriteString(fmt.Sprintf("Meta: %s\n", ui.FormatMeta(snippet.Meta)))
+		}
+		if snippet.Language != "" {
+			content.WriteString(fmt.Sprintf("Language: %s\n", snippet.Language))
+		}
+		if snippet.Description != "" {
+			content.WriteString(fmt.Sprintf("Description: %s\n", snippet.Description))
+		}
+		if snippet.SourceURL != "" {
+			content.WriteString(fmt.Sprintf("Source: %s\n", snippet.SourceURL))
+		}
 		
-		content.WriteString(fmt.Sprintf("Created: %s\n\n", snippet.CreatedAt.Format("January 2, 2006")))
-		content.WriteString(snippet.Content)
-		content.WriteString("\n\n")
+		content.WriteString(fmt.Sprintf("Created: %s\n", ui.LocalTime(snippet.CreatedAt).Format("January 2, 2006")))
+		if snippet.Encrypted {
+			content.WriteString("Encrypted: yes\n")
+		}
+		content.WriteString("\n")
+		if len(snippet.Files) > 0 {
+			content.WriteString(storage.JoinFiles(snippet.Files))
+			content.WriteString("\n")
+		} else {
+			content.WriteString(snippet.Content)
+			content.WriteString("\n\n")
+		}
 		
 		if i < len(snippets)-1 {
 			content.WriteString(strings.Repeat("=", 50) + "\n\n")
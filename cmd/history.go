@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [id|slug|title]",
+	Short: "Show the revision history of a snippet",
+	Long:  `List every saved revision of a snippet. Every edit records a new revision; use 'snip diff' to compare them and 'snip revert' to restore one.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		revisions, err := store.History(snippet.ID)
+		if err != nil {
+			fmt.Println(ui.RenderError("Error loading history: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderTitle(fmt.Sprintf("%s History of '%s'", ui.IconTime, snippet.Title)))
+		fmt.Println()
+		fmt.Println(ui.RenderRevisionsTable(revisions, snippet.Encrypted))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
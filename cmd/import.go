@@ -16,17 +16,38 @@ import (
 )
 
 var (
-	importFile   string
-	importFormat string
-	skipConfirm  bool
+	importFile        string
+	importFormat      string
+	importOnDuplicate string
+	skipConfirm       bool
+)
+
+// Outcomes of importing a single snippet
+const (
+	importCreated = iota
+	importSkipped
+	importMerged
 )
 
 var importCmd = &cobra.Command{
 	Use:   "import [file]",
 	Short: "Import snippets from a file",
-	Long:  `Import snippets from a JSON export file. Use this to restore backups or migrate snippets.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Import snippets from a JSON export file. Use this to restore backups or migrate snippets.
+
+Snippets whose content already exists in your collection are handled according to --on-duplicate:
+  skip   leave the existing snippet alone (default)
+  merge  add the imported tags to the existing snippet
+  new    import it as a separate snippet anyway`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
+		switch importOnDuplicate {
+		case "skip", "merge", "new":
+		default:
+			fmt.Println(ui.RenderError("Unsupported --on-duplicate policy. Use: skip, merge, or new"))
+			return
+		}
+
 		// Determine input file
 		inputFile := importFile
 		if len(args) > 0 {
@@ -81,7 +102,7 @@ var importCmd = &cobra.Command{
   • Version: %s
   • Snippets to import: %d`,
 			filepath.Base(absPath),
-			importData.ExportedAt.Format("January 2, 2006"),
+			ui.LocalTime(importData.ExportedAt).Format("January 2, 2006"),
 			importData.Version,
 			len(importData.Snippets))
 
@@ -129,7 +150,7 @@ var importCmd = &cobra.Command{
 				huh.NewGroup(
 					huh.NewConfirm().
 						Title(fmt.Sprintf("Import %d snippets?", len(importData.Snippets))).
-						Description("This will add the snippets to your collection. Existing snippets will not be affected.").
+						Description(fmt.Sprintf("This will add the snippets to your collection. Duplicates of existing snippets: %s.", importOnDuplicate)).
 						Affirmative("Yes, import them!").
 						Negative("Cancel").
 						Value(&confirm),
@@ -149,42 +170,82 @@ var importCmd = &cobra.Command{
 		}
 
 		// Import snippets
+		backupBefore("import")
 		fmt.Println(ui.RenderInfo("Importing snippets..."))
 		fmt.Println()
 
 		imported := 0
+		skipped := 0
+		merged := 0
 		failed := 0
 
-		for _, snippet := range importData.Snippets {
-			// Create new snippet (without ID to get auto-generated ID)
-			newSnippet := models.Snippet{
-				Title:     snippet.Title,
-				Tags:      snippet.Tags,
-				CreatedAt: time.Now(), // Use current time for imported snippets
-				Content:   snippet.Content,
-			}
+		// One transaction makes the import all-or-nothing and far faster
+		// than committing every snippet on its own
+		err = inTransaction(func(tx storage.Store) error {
+			// Imported snippets by the UUID they were exported with, for links
+			ids := make(map[string]int)
+			for _, snippet := range importData.Snippets {
+				// Create new snippet (without ID to get auto-generated ID)
+				newSnippet := models.Snippet{
+					UUID:      snippet.UUID,
+					Slug:      snippet.Slug,
+					Title:     snippet.Title,
+					Tags:      snippet.Tags,
+					CreatedAt: time.Now(), // Use current time for imported snippets
+					Encrypted: snippet.Encrypted,
+					Content:   snippet.Content,
+					Files:     snippet.Files,
 
-			_, err := storage.SaveSnippet(newSnippet)
-			if err != nil {
-				fmt.Printf("  %s Failed to import: %s\n", ui.IconError, snippet.Title)
-				failed++
-			} else {
-				fmt.Printf("  %s Imported: %s\n", ui.IconSuccess, snippet.Title)
-				imported++
+					Language:    snippet.Language,
+					Description: snippet.Description,
+					SourceURL:   snippet.SourceURL,
+					Collection:  snippet.Collection,
+					Pinned:      snippet.Pinned,
+					Meta:        snippet.Meta,
+				}
+
+				outcome, id, err := importSnippet(tx, newSnippet, importOnDuplicate)
+				if err == nil && snippet.UUID != "" {
+					ids[strings.ToLower(snippet.UUID)] = id
+				}
+				switch {
+				case err != nil:
+					fmt.Printf("  %s Failed to import: %s (%s)\n", ui.IconError, snippet.Title, err.Error())
+					failed++
+				case outcome == importSkipped:
+					fmt.Printf("  %s Skipped duplicate: %s\n", ui.IconInfo, snippet.Title)
+					skipped++
+				case outcome == importMerged:
+					fmt.Printf("  %s Merged tags into existing: %s\n", ui.IconTag, snippet.Title)
+					merged++
+				default:
+					fmt.Printf("  %s Imported: %s\n", ui.IconSuccess, snippet.Title)
+					imported++
+				}
 			}
+			return importLinks(tx, importData.Snippets, ids)
+		})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error importing snippets, nothing was imported: " + err.Error()))
+			return
 		}
 
 		fmt.Println()
 
 		// Show results
-		if failed == 0 {
+		if failed == 0 && skipped == 0 && merged == 0 {
 			fmt.Println(ui.RenderSuccess(fmt.Sprintf("Successfully imported all %d snippets!", imported)))
 		} else {
-			fmt.Println(ui.RenderWarning(fmt.Sprintf("Imported %d snippets, %d failed", imported, failed)))
+			summary := fmt.Sprintf(`📦 Import Summary:
+  • Imported: %d
+  • Skipped duplicates: %d
+  • Merged into existing: %d
+  • Failed: %d`, imported, skipped, merged, failed)
+			fmt.Println(ui.RenderBox(summary))
 		}
 
 		// Show next steps
-		if imported > 0 {
+		if imported > 0 || merged > 0 {
 			nextSteps := `🎉 Import completed!
   • Use 'snip list' to see all your snippets
   • Use 'snip stats' to see updated statistics
@@ -196,8 +257,96 @@ var importCmd = &cobra.Command{
 	},
 }
 
+// importSnippet saves one imported snippet, applying the duplicate policy
+// when it, or its content, is already in the collection. It returns the ID
+// of the snippet the import ended up as: the new one, or the existing one.
+func importSnippet(store storage.Store, snippet models.Snippet, onDuplicate string) (int, int, error) {
+	// A snippet with the same UUID is the same snippet, imported before
+	var sameUUID *models.Snippet
+	if snippet.UUID != "" {
+		if existing, err := store.Lookup(snippet.UUID); err == nil {
+			sameUUID = existing
+		}
+	}
+
+	if onDuplicate == "new" && sameUUID != nil {
+		// Keep both, so the copy needs identifiers of its own
+		snippet.UUID = ""
+		snippet.Slug = ""
+	}
+
+	if onDuplicate != "new" {
+		duplicates, err := store.FindDuplicates(snippet)
+		if err != nil {
+			return 0, 0, err
+		}
+		if sameUUID != nil {
+			duplicates = append([]models.Snippet{*sameUUID}, duplicates...)
+		}
+
+		if len(duplicates) > 0 {
+			existing := duplicates[0]
+			if onDuplicate == "skip" {
+				return importSkipped, existing.ID, nil
+			}
+
+			mergedTags := append(append([]string(nil), existing.Tags...), snippet.Tags...)
+			if len(storage.CleanTags(mergedTags)) == len(storage.CleanTags(existing.Tags)) {
+				// Nothing new to add
+				return importSkipped, existing.ID, nil
+			}
+
+			existing.Tags = storage.CleanTags(mergedTags)
+			if err := store.Update(existing); err != nil {
+				return 0, 0, err
+			}
+			return importMerged, existing.ID, nil
+		}
+	}
+
+	id, err := store.Save(snippet)
+	if err != nil {
+		return 0, 0, err
+	}
+	return importCreated, int(id), nil
+}
+
+// importLinks restores the links between imported snippets. ids maps the
+// UUIDs in the export to the snippets they were imported as; links to
+// snippets that aren't in the export are kept when the library has them.
+func importLinks(store storage.Store, snippets []models.Snippet, ids map[string]int) error {
+	idOf := func(uuid string) int {
+		uuid = strings.ToLower(uuid)
+		if uuid == "" {
+			return 0
+		}
+		if id, ok := ids[uuid]; ok {
+			return id
+		}
+		if existing, err := store.Lookup(uuid); err == nil {
+			return existing.ID
+		}
+		return 0
+	}
+
+	for _, snippet := range snippets {
+		from := idOf(snippet.UUID)
+		for _, link := range snippet.Links {
+			to := idOf(link.UUID)
+			if _, err := storage.CheckRelation(link.Rel); err != nil || from == 0 || to == 0 || from == to {
+				continue
+			}
+			if err := store.Link(from, to, link.Rel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func init() {
 	importCmd.Flags().StringVarP(&importFile, "file", "f", "", "File to import from")
 	importCmd.Flags().BoolVar(&skipConfirm, "yes", false, "Skip confirmation prompt")
+	importCmd.Flags().StringVar(&importOnDuplicate, "on-duplicate", "skip", "What to do with snippets that already exist (skip, merge, new)")
 	rootCmd.AddCommand(importCmd)
 }
@@ -5,14 +5,26 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initBackend string
+	initPath    string
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize snip configuration",
-	Long:  `Set up the snip configuration directory and database. This is optional - snip will work without explicit initialization.`,
+	Long: `Set up the snip configuration directory and database. This is optional - snip will work without explicit initialization.
+
+Use --backend=files to keep the default library as a directory of plain
+files, one <slug>.<ext> per snippet with a YAML header, instead of a SQLite
+database. --path chooses where the library is stored.`,
+	Annotations: map[string]string{noStoreAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show welcome header
 		fmt.Println(ui.RenderTitle(ui.IconRocket + " Initializing snip"))
@@ -40,9 +52,15 @@ var initCmd = &cobra.Command{
 			fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s Created configuration directory: %s", ui.IconFolder, snipDir)))
 		}
 
-		// Database will be created automatically when first used
-		dbPath := filepath.Join(snipDir, "snippets.db")
-		fmt.Println(ui.RenderInfo(fmt.Sprintf("%s Database will be stored at: %s", ui.IconDatabase, dbPath)))
+		if cmd.Flags().Changed("backend") || cmd.Flags().Changed("path") {
+			if !initBackendLibrary() {
+				return
+			}
+		} else {
+			// Database will be created automatically when first used
+			dbPath := filepath.Join(snipDir, "snippets.db")
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("%s Database will be stored at: %s", ui.IconDatabase, dbPath)))
+		}
 		fmt.Println()
 
 		fmt.Println(ui.RenderSuccess(ui.IconSparkles + " snip is ready to use!"))
@@ -59,6 +77,53 @@ var initCmd = &cobra.Command{
 	},
 }
 
+// initBackendLibrary points the default library at the backend and path
+// given on the command line and creates it. It reports whether it succeeded.
+func initBackendLibrary() bool {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(ui.RenderError("Error reading config: " + err.Error()))
+		return false
+	}
+
+	previous, err := cfg.Library(config.DefaultLibrary)
+	if err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return false
+	}
+
+	lib, err := cfg.SetDefaultBackend(initBackend, initPath)
+	if err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return false
+	}
+
+	// Opening the store creates it
+	s, err := lib.Open()
+	if err != nil {
+		fmt.Println(ui.RenderError("Error creating library: " + err.Error()))
+		return false
+	}
+	s.Close()
+
+	if err := cfg.Save(); err != nil {
+		fmt.Println(ui.RenderError("Error saving config: " + err.Error()))
+		return false
+	}
+
+	if lib.Backend == storage.BackendFiles {
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s Snippets will be stored as plain files in: %s", ui.IconFolder, lib.Path)))
+	} else {
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s Database will be stored at: %s", ui.IconDatabase, lib.Path)))
+	}
+	if previous.Path != lib.Path {
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("The previous library at %s was left untouched. Move snippets over with 'snip export' and 'snip import'.", previous.Path)))
+	}
+	return true
+}
+
 func init() {
+	initCmd.Flags().StringVar(&initBackend, "backend", storage.BackendSQLite, "Storage backend for the default library: sqlite or files")
+	initCmd.Flags().StringVar(&initPath, "path", "", "Where to store the default library (default ~/.snipdb/snippets.db, or ~/.snipdb/snippets for files)")
 	rootCmd.AddCommand(initCmd)
 }
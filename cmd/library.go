@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	libraryPath    string
+	libraryBackend string
+)
+
+var libraryCmd = &cobra.Command{
+	Use:     "library",
+	Aliases: []string{"lib"},
+	Short:   "List and manage snippet libraries",
+	Long: `Libraries keep separate sets of snippets, such as work and personal ones, each in its own database.
+Commands use the current library, chosen with 'snip library use', unless --library names another one.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noStoreAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading config: " + err.Error()))
+			return
+		}
+
+		current, err := cfg.Current()
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderTitle(ui.IconList + " Libraries"))
+		fmt.Println()
+
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(ui.Border)).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return lipgloss.NewStyle().
+						Foreground(ui.Primary).
+						Bold(true).
+						Align(lipgloss.Center).
+						Padding(0, 1)
+				}
+				return lipgloss.NewStyle().
+					Foreground(ui.Text).
+					Padding(0, 1)
+			}).
+			Headers("", "Name", "Snippets", "Path")
+
+		for _, lib := range cfg.AllLibraries() {
+			marker := ""
+			if lib.Name == current.Name {
+				marker = ui.SuccessStyle.Render("*")
+			}
+
+			count := "-"
+			if s, err := lib.Open(); err == nil {
+				if snippets, err := s.List(storage.ListOptions{}); err == nil {
+					count = fmt.Sprintf("%d", len(snippets))
+				}
+				s.Close()
+			}
+
+			t.Row(marker, lib.Name, count, lib.Path)
+		}
+
+		fmt.Println(t.Render())
+	},
+}
+
+var libraryCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new library",
+	Long:  `Create an empty library. Its database is stored under ~/.snipdb/libraries/ unless --path gives another location. With --backend=files the library is a directory of plain snippet files instead.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading config: " + err.Error()))
+			return
+		}
+
+		lib, err := cfg.AddLibrary(args[0], libraryPath, libraryBackend)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		// Opening the store creates it and, for a database, applies the schema
+		s, err := lib.Open()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error creating library: " + err.Error()))
+			return
+		}
+		s.Close()
+
+		if err := cfg.Save(); err != nil {
+			fmt.Println(ui.RenderError("Error saving config: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Created library '%s' at %s", lib.Name, lib.Path)))
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("Switch to it with 'snip library use %s'", lib.Name)))
+	},
+}
+
+var libraryUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current library",
+	Long:  `Make the named library the one commands use by default.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading config: " + err.Error()))
+			return
+		}
+
+		lib, err := cfg.Library(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		cfg.CurrentLibrary = lib.Name
+		if lib.Name == config.DefaultLibrary {
+			cfg.CurrentLibrary = ""
+		}
+		if err := cfg.Save(); err != nil {
+			fmt.Println(ui.RenderError("Error saving config: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Now using library '%s'", lib.Name)))
+	},
+}
+
+// queryAllLibraries runs query against every library and returns the
+// combined results, each labelled with the library it came from
+func queryAllLibraries(query func(s storage.Store) ([]models.Snippet, error)) ([]models.Snippet, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Snippet
+	for _, lib := range cfg.AllLibraries() {
+		snippets, err := queryLibrary(lib, query)
+		if err != nil {
+			return nil, fmt.Errorf("library '%s': %w", lib.Name, err)
+		}
+		for i := range snippets {
+			snippets[i].Library = lib.Name
+		}
+		results = append(results, snippets...)
+	}
+
+	return results, nil
+}
+
+// queryLibrary runs query against a library, reusing the open store when
+// it belongs to that library
+func queryLibrary(lib config.Library, query func(s storage.Store) ([]models.Snippet, error)) ([]models.Snippet, error) {
+	if store != nil && lib.Name == library.Name {
+		return query(store)
+	}
+
+	s, err := lib.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	return query(s)
+}
+
+func init() {
+	libraryCreateCmd.Flags().StringVar(&libraryPath, "path", "", "Where to store the library's database or directory")
+	libraryCreateCmd.Flags().StringVar(&libraryBackend, "backend", storage.BackendSQLite, "Storage backend: sqlite or files")
+	libraryCmd.AddCommand(libraryCreateCmd)
+	libraryCmd.AddCommand(libraryUseCmd)
+	rootCmd.AddCommand(libraryCmd)
+}
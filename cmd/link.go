@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var linkRel string
+
+var linkCmd = &cobra.Command{
+	Use:   "link [id|slug|title] [id|slug|title]",
+	Short: "Link two related snippets",
+	Long: `Link two snippets that belong together, such as setup and teardown or a
+client and its server. Both show the other under "Related" in 'snip show'.
+
+--rel says how they relate: see-also (default) or depends-on, meaning the
+first snippet needs the second. Linking the same snippets again changes the
+relation.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		to, err := resolveSnippet(args[1])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		rel, err := storage.CheckRelation(linkRel)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		if err := store.Link(from.ID, to.ID, rel); err != nil {
+			fmt.Println(ui.RenderError("Error linking snippets: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Linked '%s' (ID: %d) %s '%s' (ID: %d)",
+			from.Title, from.ID, strings.ReplaceAll(rel, "-", " "), to.Title, to.ID)))
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink [id|slug|title] [id|slug|title]",
+	Short: "Remove the link between two snippets",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		b, err := resolveSnippet(args[1])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		if !linked(a.Links, b.ID) && !linked(b.Links, a.ID) {
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("'%s' and '%s' aren't linked", a.Title, b.Title)))
+			return
+		}
+
+		// Links are removed in both directions
+		err = inTransaction(func(tx storage.Store) error {
+			if err := tx.Unlink(a.ID, b.ID); err != nil {
+				return err
+			}
+			return tx.Unlink(b.ID, a.ID)
+		})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error unlinking snippets: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Unlinked '%s' and '%s'", a.Title, b.Title)))
+	},
+}
+
+// linked reports whether links include one to the snippet with the given ID
+func linked(links []models.Link, id int) bool {
+	for _, link := range links {
+		if link.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	linkCmd.Flags().StringVar(&linkRel, "rel", storage.LinkSeeAlso, "Relation: "+strings.Join(storage.LinkRelations, ", "))
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+}
@@ -2,34 +2,116 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/models"
 	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listSort         string
+	listAllLibraries bool
+	listIn           string
+	listRecursive    bool
+	listPinned       bool
+	listMeta         []string
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all snippets",
-	Long:  `Display all saved snippets with their ID, title, and tags.`,
+	Long:  `Display all saved snippets with their ID, title, and tags. Use --sort to order them by creation, last update, last use, use count or title, --in to show one collection (with --recursive, the collections nested in it too), --pinned to show only pinned snippets, --meta key=value to filter by metadata, and --all-libraries to list every library at once.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		snippets, err := storage.ListAllSnippets()
+		sort := storage.SortOrder(listSort)
+		collection, err := storage.CleanCollection(listIn)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		opts := storage.ListOptions{Sort: sort, Collection: collection, Recursive: listRecursive, Pinned: listPinned}
+		for _, pair := range listMeta {
+			key, value, err := storage.ParseMeta(pair)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if opts.Meta == nil {
+				opts.Meta = make(map[string]string)
+			}
+			opts.Meta[key] = value
+		}
+		list := func(s storage.Store) ([]models.Snippet, error) {
+			return s.List(opts)
+		}
+
+		var snippets []models.Snippet
+		if listAllLibraries {
+			snippets, err = queryAllLibraries(list)
+		} else {
+			snippets, err = list(store)
+		}
 		if err != nil {
 			fmt.Println(ui.RenderError("Error listing snippets: " + err.Error()))
 			return
 		}
 
 		// Show header
-		fmt.Println(ui.RenderTitle(ui.IconList + " Your Code Snippets"))
+		header := ui.IconList + " Your Code Snippets"
+		if listPinned {
+			header = ui.IconPin + " Pinned Snippets"
+		}
+		if collection != "" {
+			header += " in " + collection
+			if listRecursive {
+				header += "/…"
+			}
+		}
+		fmt.Println(ui.RenderTitle(header + libraryLabel(listAllLibraries)))
 		fmt.Println()
 
 		// Render the beautiful table
-		fmt.Println(ui.RenderSnippetsTable(snippets))
+		fmt.Println(ui.RenderSnippetsTable(snippets, timeColumnFor(sort)))
 	},
 }
 
+// libraryLabel names the libraries a command's results come from, for
+// headers. The default library isn't mentioned.
+func libraryLabel(allLibraries bool) string {
+	switch {
+	case allLibraries:
+		return " (all libraries)"
+	case library.Name != "" && library.Name != config.DefaultLibrary:
+		return fmt.Sprintf(" (%s)", library.Name)
+	default:
+		return ""
+	}
+}
 
+// timeColumnFor picks the timestamp column that best explains a sort order
+func timeColumnFor(sort storage.SortOrder) ui.TimeColumn {
+	switch sort {
+	case storage.SortUpdated:
+		return ui.ColumnUpdated
+	case storage.SortLastUsed, storage.SortUseCount:
+		return ui.ColumnLastUsed
+	default:
+		return ui.ColumnCreated
+	}
+}
 
 func init() {
+	var orders []string
+	for _, order := range storage.SortOrders {
+		orders = append(orders, string(order))
+	}
+	listCmd.Flags().StringVarP(&listSort, "sort", "s", string(storage.SortCreated), "Sort by "+strings.Join(orders, ", "))
+	listCmd.Flags().StringVar(&listIn, "in", "", "Only list snippets in this collection, e.g. infra/k8s")
+	listCmd.Flags().BoolVarP(&listRecursive, "recursive", "r", false, "With --in, include the collections nested in it")
+	listCmd.Flags().BoolVar(&listPinned, "pinned", false, "Only list pinned snippets")
+	listCmd.Flags().StringArrayVar(&listMeta, "meta", nil, "Only list snippets with this metadata, e.g. env=prod (repeatable)")
+	listCmd.Flags().BoolVarP(&listAllLibraries, "all-libraries", "A", false, "List snippets from every library")
 	rootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Set and read custom metadata fields",
+	Long: `Attach custom key/value fields such as owner=platform, ticket=OPS-123 or
+env=prod to snippets. Keys are lowercase letters, digits, '.', '_' and '-'.
+Filter by them with 'snip list --meta key=value'.`,
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:   "set [id|slug|title] [key=value]...",
+	Short: "Set metadata fields on a snippet",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		fields := make(map[string]string)
+		var keys []string
+		for _, pair := range args[1:] {
+			key, value, err := storage.ParseMeta(pair)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if _, ok := fields[key]; !ok {
+				keys = append(keys, key)
+			}
+			fields[key] = value
+		}
+
+		err = inTransaction(func(tx storage.Store) error {
+			for _, key := range keys {
+				if err := tx.SetMeta(snippet.ID, key, fields[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error setting metadata: " + err.Error()))
+			return
+		}
+
+		for _, key := range keys {
+			fmt.Println(ui.RenderSuccess(fmt.Sprintf("Set %s=%s on '%s' (ID: %d)", key, fields[key], snippet.Title, snippet.ID)))
+		}
+	},
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:   "get [id|slug|title] [key]",
+	Short: "Print a snippet's metadata",
+	Long:  `Print one metadata value, or every field as key=value lines. The output is plain text, for scripts.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		if len(args) == 2 {
+			key, err := storage.CleanMetaKey(args[1])
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			value, ok := snippet.Meta[key]
+			if !ok {
+				fmt.Println(ui.RenderError(fmt.Sprintf("'%s' has no '%s' field", snippet.Title, key)))
+				return
+			}
+			fmt.Println(value)
+			return
+		}
+
+		keys := make([]string, 0, len(snippet.Meta))
+		for key := range snippet.Meta {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, snippet.Meta[key])
+		}
+	},
+}
+
+var metaUnsetCmd = &cobra.Command{
+	Use:   "unset [id|slug|title] [key]...",
+	Short: "Remove metadata fields from a snippet",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		var keys []string
+		for _, key := range args[1:] {
+			key, err := storage.CleanMetaKey(key)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if _, ok := snippet.Meta[key]; !ok {
+				fmt.Println(ui.RenderInfo(fmt.Sprintf("'%s' has no '%s' field", snippet.Title, key)))
+				continue
+			}
+			keys = append(keys, key)
+		}
+
+		err = inTransaction(func(tx storage.Store) error {
+			for _, key := range keys {
+				if err := tx.UnsetMeta(snippet.ID, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error removing metadata: " + err.Error()))
+			return
+		}
+
+		for _, key := range keys {
+			fmt.Println(ui.RenderSuccess(fmt.Sprintf("Removed %s from '%s' (ID: %d)", key, snippet.Title, snippet.ID)))
+		}
+	},
+}
+
+func init() {
+	metaCmd.AddCommand(metaSetCmd)
+	metaCmd.AddCommand(metaGetCmd)
+	metaCmd.AddCommand(metaUnsetCmd)
+	rootCmd.AddCommand(metaCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv [id|slug|title] [collection]",
+	Short: "Move a snippet to another collection",
+	Long: `File a snippet in a collection such as infra/k8s/debug. Collections nest with
+slashes and are created as needed. Use "/" to take a snippet out of every
+collection.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		collection, err := storage.CleanCollection(args[1])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		if collection == snippet.Collection {
+			fmt.Println(ui.RenderInfo(fmt.Sprintf("'%s' is already in %s", snippet.Title, collectionLabel(collection))))
+			return
+		}
+
+		if err := store.Move(snippet.ID, collection); err != nil {
+			fmt.Println(ui.RenderError("Error moving snippet: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Moved '%s' (ID: %d) to %s", snippet.Title, snippet.ID, collectionLabel(collection))))
+	},
+}
+
+// collectionLabel names a collection in messages
+func collectionLabel(collection string) string {
+	if collection == "" {
+		return "no collection"
+	}
+	return "'" + collection + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/secret"
+	"golang.org/x/term"
+)
+
+// passphraseEnv lets scripts supply the passphrase for encrypted snippets
+// instead of typing it
+const passphraseEnv = "SNIP_PASSPHRASE"
+
+// readPassphrase asks for a passphrase on the terminal. It reads from the
+// terminal itself rather than stdin, which may be carrying snippet content,
+// and prompts on stderr so stdout stays clean for pipes. With confirm, the
+// passphrase has to be typed twice.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	ttyPath := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		ttyPath = "CONIN$"
+	}
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("a terminal is needed to enter the passphrase (or set %s): %w", passphraseEnv, err)
+	}
+	defer tty.Close()
+
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(passphrase), err
+	}
+
+	passphrase, err := read(prompt)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase cannot be empty")
+	}
+
+	if confirm {
+		again, err := read("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// encryptSnippet replaces the content, or every file, of a snippet with
+// ciphertext and marks it encrypted
+func encryptSnippet(snippet *models.Snippet, passphrase string) error {
+	if len(snippet.Files) == 0 {
+		content, err := secret.Encrypt(snippet.Content, passphrase)
+		if err != nil {
+			return err
+		}
+		snippet.Content = content
+	}
+
+	for i, file := range snippet.Files {
+		content, err := secret.Encrypt(file.Content, passphrase)
+		if err != nil {
+			return err
+		}
+		snippet.Files[i].Content = content
+	}
+
+	snippet.Encrypted = true
+	return nil
+}
+
+// decryptSnippet replaces the ciphertext of an encrypted snippet with its
+// plaintext, asking for the passphrase. It returns the passphrase so changes
+// can be encrypted again; for plain snippets it does nothing.
+func decryptSnippet(snippet *models.Snippet) (string, error) {
+	if !snippet.Encrypted {
+		return "", nil
+	}
+
+	passphrase, err := readPassphrase(fmt.Sprintf("Passphrase for snippet %d: ", snippet.ID), false)
+	if err != nil {
+		return "", err
+	}
+
+	snippet.Content, snippet.Files, err = decryptContent(snippet.Content, snippet.Files, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return passphrase, nil
+}
+
+// decryptContent decrypts whichever of content and files hold ciphertext
+func decryptContent(content string, files []models.File, passphrase string) (string, []models.File, error) {
+	var err error
+	if secret.IsEncrypted(content) {
+		content, err = secret.Decrypt(content, passphrase)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	var decrypted []models.File
+	for _, file := range files {
+		if secret.IsEncrypted(file.Content) {
+			file.Content, err = secret.Decrypt(file.Content, passphrase)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		decrypted = append(decrypted, file)
+	}
+
+	return content, decrypted, nil
+}
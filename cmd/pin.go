@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [id|slug|title]",
+	Short: "Pin a snippet to the top of list and search",
+	Long:  `Pin a snippet so it is shown in a section above all others in 'snip list' and 'snip search'. Use --pinned on either to show only pinned snippets.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setPinned(args[0], true)
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin [id|slug|title]",
+	Short: "Unpin a snippet",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setPinned(args[0], false)
+	},
+}
+
+// setPinned pins or unpins the snippet ref refers to
+func setPinned(ref string, pinned bool) {
+	snippet, err := resolveSnippet(ref)
+	if err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return
+	}
+
+	if snippet.Pinned == pinned {
+		state := "not pinned"
+		if pinned {
+			state = "already pinned"
+		}
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("'%s' is %s", snippet.Title, state)))
+		return
+	}
+
+	if err := store.Pin(snippet.ID, pinned); err != nil {
+		fmt.Println(ui.RenderError("Error updating snippet: " + err.Error()))
+		return
+	}
+
+	action := "Unpinned"
+	if pinned {
+		action = "Pinned"
+	}
+	fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s '%s' (ID: %d)", action, snippet.Title, snippet.ID)))
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+	"golang.org/x/term"
+)
+
+// resolveSnippet finds the snippet a command argument refers to. ref may be
+// a numeric ID, a UUID, a slug, an exact title or the start of a title or
+// slug. Ambiguous refs are settled by pickSnippet.
+func resolveSnippet(ref string) (*models.Snippet, error) {
+	snippet, err := store.Lookup(ref)
+	if err == nil {
+		return snippet, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	candidates, err := store.FindByTitle(ref)
+	if err != nil {
+		return nil, err
+	}
+	return pickSnippet(ref, candidates)
+}
+
+// resolveTrashed finds the snippet in the trash a command argument refers
+// to, matching ref the same way resolveSnippet does
+func resolveTrashed(ref string) (*models.Snippet, error) {
+	trash, err := store.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := strconv.Atoi(ref)
+	for i, s := range trash {
+		if s.ID == id || s.UUID == strings.ToLower(ref) || s.Slug == ref {
+			return &trash[i], nil
+		}
+	}
+
+	var exact, prefix []models.Snippet
+	lower := strings.ToLower(ref)
+	for _, s := range trash {
+		switch {
+		case strings.EqualFold(s.Title, ref):
+			exact = append(exact, s)
+		case strings.HasPrefix(strings.ToLower(s.Title), lower) || strings.HasPrefix(s.Slug, lower):
+			prefix = append(prefix, s)
+		}
+	}
+	if len(exact) > 0 {
+		return pickSnippet(ref, exact)
+	}
+	if len(prefix) == 0 {
+		return nil, fmt.Errorf("no snippet in the trash matches '%s'. See 'snip trash'", ref)
+	}
+	return pickSnippet(ref, prefix)
+}
+
+// pickSnippet narrows the snippets matching ref down to one. When several
+// match, the user picks one in a terminal; otherwise the candidates are
+// listed in the error.
+func pickSnippet(ref string, candidates []models.Snippet) (*models.Snippet, error) {
+	switch {
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("no snippet matches '%s'. Use an ID, UUID, slug or title", ref)
+	case len(candidates) == 1:
+		return &candidates[0], nil
+	case isInteractive():
+		return chooseSnippet(ref, candidates)
+	default:
+		var list strings.Builder
+		for _, c := range candidates {
+			list.WriteString(fmt.Sprintf("\n  %d  %s  (%s)", c.ID, c.Title, c.Slug))
+		}
+		return nil, fmt.Errorf("'%s' matches %d snippets:%s\nUse an ID or slug to pick one.", ref, len(candidates), list.String())
+	}
+}
+
+// chooseSnippet asks the user to pick one of several matching snippets
+func chooseSnippet(ref string, candidates []models.Snippet) (*models.Snippet, error) {
+	var options []huh.Option[int]
+	for i, c := range candidates {
+		options = append(options, huh.NewOption(fmt.Sprintf("%d: %s (%s)", c.ID, c.Title, c.Slug), i))
+	}
+
+	var choice int
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title(fmt.Sprintf("'%s' matches %d snippets. Which one?", ref, len(candidates))).
+				Options(options...).
+				Value(&choice),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+	return &candidates[choice], nil
+}
+
+// isInteractive reports whether both stdin and stdout are terminals, so a
+// prompt can be shown without corrupting piped output
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
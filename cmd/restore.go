@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [id|slug|title]",
+	Short: "Restore a deleted snippet from the trash",
+	Long:  `Move a snippet out of the trash so it shows up in list, search and export again.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveTrashed(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		err = store.Restore(snippet.ID)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Restored snippet '%s' (ID: %d)", snippet.Title, snippet.ID)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+}
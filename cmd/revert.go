@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert [id|slug|title] [rev]",
+	Short: "Restore a snippet to an earlier revision",
+	Long:  `Restore the title, tags and content of a snippet from an earlier revision. The revert is saved as a new revision, so it can itself be undone.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		number, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println(ui.RenderError("Invalid revision. Please provide a valid number."))
+			return
+		}
+
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		revision, err := store.Revision(snippet.ID, number)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		snippet.Title = revision.Title
+		snippet.Tags = revision.Tags
+		snippet.Content = revision.Content
+		snippet.Files = revision.Files
+
+		err = store.Update(*snippet)
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reverting snippet: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Reverted snippet '%s' to revision %d", snippet.Title, number)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+}
@@ -1,19 +1,41 @@
 package cmd
 
 import (
-    "fmt"
-    "os"
+	"fmt"
+	"os"
 
-    "github.com/lubasinkal/snip/internal/ui"
-    "github.com/spf13/cobra"
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
 )
 
+// noStoreAnnotation marks commands that never touch the snippet store, so
+// they keep working when the database is missing or unwritable
+const noStoreAnnotation = "snip/no-store"
+
+// noMigrateAnnotation marks commands that open the store without applying
+// pending migrations, so they can report and apply them themselves
+const noMigrateAnnotation = "snip/no-migrate"
+
+// store is the snippet store shared by every command. It is opened by the
+// root command just before a command that needs it runs; tests may set it
+// to an in-memory store beforehand.
+var store storage.Store
+
+// library is the library store was opened from
+var library config.Library
+
+// libraryFlag selects a library for a single command, overriding the one
+// chosen with 'snip library use'
+var libraryFlag string
+
 var rootCmd = &cobra.Command{
-    Use:   "snip",
-    Short: "snip is a fast CLI code snippet manager",
-    Long: ui.RenderTitle("🚀 snip — A Terminal Code Snippet Manager") + "\n\n" +
-        ui.RenderSubtitle("A superfast command-line tool to save, search, view, and reuse your code snippets — all from your terminal.") + "\n\n" +
-        ui.RenderBox(`✨ Features:
+	Use:   "snip",
+	Short: "snip is a fast CLI code snippet manager",
+	Long: ui.RenderTitle("🚀 snip — A Terminal Code Snippet Manager") + "\n\n" +
+		ui.RenderSubtitle("A superfast command-line tool to save, search, view, and reuse your code snippets — all from your terminal.") + "\n\n" +
+		ui.RenderBox(`✨ Features:
   • 🚀 Lightning fast - Built in Go for speed
   • 💾 Local storage - Your snippets stay on your machine
   • 🔍 Powerful search - Search by title, content, or tags
@@ -21,11 +43,96 @@ var rootCmd = &cobra.Command{
   • 📋 Clipboard integration - Copy snippets directly to clipboard
   • ✏️ Edit in place - Open snippets in your favorite editor
   • 🎯 Simple CLI - Intuitive commands that just work`),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if tz := os.Getenv("SNIP_TIMEZONE"); tz != "" {
+			if err := ui.SetTimezone(tz); err != nil {
+				cmd.SilenceUsage = true
+				cmd.SilenceErrors = true
+				return fmt.Errorf("invalid SNIP_TIMEZONE: %w", err)
+			}
+		}
+
+		if store != nil || !needsStore(cmd) {
+			return nil
+		}
+
+		var err error
+		library, err = activeLibrary()
+		if err != nil {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return err
+		}
+
+		if cmd.Annotations[noMigrateAnnotation] == "true" {
+			store, err = library.OpenUnmigrated()
+		} else {
+			store, err = library.Open()
+		}
+		if err != nil {
+			// The command line was fine, so report the error once without usage text
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return fmt.Errorf("failed to open snippet database: %w", err)
+		}
+
+		if cmd.Annotations[noAutoBackupAnnotation] != "true" {
+			autoBackup()
+		}
+		return nil
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		if store == nil {
+			return nil
+		}
+		err := store.Close()
+		store = nil
+		return err
+	},
+}
+
+// inTransaction runs fn with a store whose writes are committed together,
+// or directly with store when the backend has no transactions
+func inTransaction(fn func(tx storage.Store) error) error {
+	if transactor, ok := store.(storage.Transactor); ok {
+		return transactor.Transaction(fn)
+	}
+	return fn(store)
+}
+
+// needsStore reports whether cmd, or any of its parents, uses the snippet store
+func needsStore(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+		if c.Annotations[noStoreAnnotation] == "true" {
+			return false
+		}
+	}
+	return true
+}
+
+// activeLibrary returns the library named by --library, or the current one
+func activeLibrary() (config.Library, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.Library{}, err
+	}
+	if libraryFlag != "" {
+		return cfg.Library(libraryFlag)
+	}
+	return cfg.Current()
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&libraryFlag, "library", "L", "", "Library to use instead of the current one")
 }
 
 func Execute() {
-    if err := rootCmd.Execute(); err != nil {
-        fmt.Println(err)
-        os.Exit(1)
-    }
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// memoryStore is an in-memory store that outlives the commands run against
+// it; the root command closes the store after every command
+type memoryStore struct {
+	*storage.SQLiteStore
+}
+
+func (memoryStore) Close() error {
+	return nil
+}
+
+// useMemoryStore points every command at a fresh in-memory store
+func useMemoryStore(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	mem, err := storage.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store = nil
+		mem.Close()
+	})
+	store = memoryStore{mem}
+}
+
+// runCommand runs snip with args and stdin, returning what it printed
+func runCommand(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+
+	// The root command clears store once a command finishes
+	opened := store
+	defer func() { store = opened }()
+
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		io.WriteString(inW, stdin)
+		inW.Close()
+	}()
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(outR)
+		output <- string(data)
+	}()
+
+	stdinBefore, stdoutBefore := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = inR, outW
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	os.Stdin, os.Stdout = stdinBefore, stdoutBefore
+	outW.Close()
+	inR.Close()
+
+	printed := <-output
+	if err != nil {
+		t.Fatalf("snip %s: %v\n%s", strings.Join(args, " "), err, printed)
+	}
+	return printed
+}
+
+func TestSaveListCat(t *testing.T) {
+	useMemoryStore(t)
+
+	out := runCommand(t, "docker system prune -f\n", "save", "Docker prune", "--tags", "docker,cleanup")
+	if !strings.Contains(out, "Snippet saved with ID: 1 (slug: docker-prune)") {
+		t.Errorf("save printed:\n%s", out)
+	}
+	out = runCommand(t, "fmt.Println(\"hello\")\n", "save", "Go hello", "--tags", "go")
+	if !strings.Contains(out, "Snippet saved with ID: 2 (slug: go-hello)") {
+		t.Errorf("save printed:\n%s", out)
+	}
+
+	out = runCommand(t, "", "list")
+	for _, want := range []string{"Docker prune", "Go hello", "docker", "cleanup"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list is missing %q:\n%s", want, out)
+		}
+	}
+
+	for _, ref := range []string{"1", "docker-prune", "Docker prune", "dock"} {
+		if out := runCommand(t, "", "cat", ref); out != "docker system prune -f\n" {
+			t.Errorf("cat %s printed %q", ref, out)
+		}
+	}
+	if out := runCommand(t, "", "cat", "go-hello"); out != "fmt.Println(\"hello\")\n" {
+		t.Errorf("cat go-hello printed %q", out)
+	}
+	if out := runCommand(t, "", "cat", "missing"); !strings.Contains(out, "no snippet matches 'missing'") {
+		t.Errorf("cat missing printed %q", out)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,15 +14,45 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var tags string
+var (
+	tags           string
+	allowDuplicate bool
+	encrypt        bool
+	saveFiles      []string
+	saveLanguage   string
+	saveDesc       string
+	saveSource     string
+	saveIn         string
+	saveMeta       []string
+)
 
 var saveCmd = &cobra.Command{
 	Use:   "save [title]",
 	Short: "Save a snippet from stdin",
+	Long:  `Save a snippet from stdin, or save one or more files as a multi-file snippet with --file.`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		title := args[0]
-		content, _ := io.ReadAll(os.Stdin)
+
+		// With --file the snippet is a bundle of named files and stdin is ignored
+		var content []byte
+		var files []models.File
+		if len(saveFiles) > 0 {
+			for _, path := range saveFiles {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					fmt.Println(ui.RenderError("Error reading file: " + err.Error()))
+					return
+				}
+				files = append(files, models.File{Name: filepath.Base(path), Content: string(data)})
+			}
+			if err := storage.ValidateFiles(files); err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+		} else {
+			content, _ = io.ReadAll(os.Stdin)
+		}
 
 		// Parse tags
 		var tagList []string
@@ -34,34 +65,89 @@ var saveCmd = &cobra.Command{
 		}
 
 		snippet := models.Snippet{
-			Title:     title,
-			Tags:      tagList,
-			CreatedAt: time.Now(),
-			Content:   string(content),
+			Title:       title,
+			Tags:        tagList,
+			CreatedAt:   time.Now(),
+			Language:    strings.ToLower(strings.TrimSpace(saveLanguage)),
+			Description: strings.TrimSpace(saveDesc),
+			SourceURL:   strings.TrimSpace(saveSource),
+			Collection:  saveIn,
+			Content:     string(content),
+			Files:       files,
 		}
-		id, err := storage.SaveSnippet(snippet)
+
+		for _, pair := range saveMeta {
+			key, value, err := storage.ParseMeta(pair)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if snippet.Meta == nil {
+				snippet.Meta = make(map[string]string)
+			}
+			snippet.Meta[key] = value
+		}
+
+		if encrypt {
+			passphrase, err := readPassphrase("Passphrase: ", true)
+			if err != nil {
+				fmt.Println(ui.RenderError("Error reading passphrase: " + err.Error()))
+				return
+			}
+
+			if err := encryptSnippet(&snippet, passphrase); err != nil {
+				fmt.Println(ui.RenderError("Error encrypting snippet: " + err.Error()))
+				return
+			}
+		}
+
+		// Refuse to save the same content twice unless asked to. Ciphertext
+		// is different every time, so encrypted snippets are never compared.
+		if !allowDuplicate && !snippet.Encrypted {
+			duplicates, err := store.FindDuplicates(snippet)
+			if err != nil {
+				fmt.Println(ui.RenderError("Error checking for duplicates: " + err.Error()))
+				return
+			}
+			if len(duplicates) > 0 {
+				existing := duplicates[0]
+				fmt.Println(ui.RenderWarning(fmt.Sprintf("This content is already saved as snippet %d: '%s'", existing.ID, existing.Title)))
+				fmt.Println(ui.RenderInfo("Use --allow-duplicate to save it anyway."))
+				return
+			}
+		}
+
+		id, err := store.Save(snippet)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error saving snippet: " + err.Error()))
 			return
 		}
 
+		// Reload to pick up the UUID and slug assigned on save
+		snippet.ID = int(id)
+		if saved, err := store.Get(snippet.ID); err == nil {
+			snippet = *saved
+		}
+
 		// Show success message with snippet details
-		successMsg := fmt.Sprintf("Snippet saved with ID: %d", id)
+		successMsg := fmt.Sprintf("Snippet saved with ID: %d (slug: %s)", id, snippet.Slug)
 		fmt.Println(ui.RenderSuccess(successMsg))
 
 		// Show a preview of what was saved
 		fmt.Println()
-		fmt.Println(ui.RenderSnippetCard(models.Snippet{
-			ID:        int(id),
-			Title:     snippet.Title,
-			Tags:      snippet.Tags,
-			CreatedAt: snippet.CreatedAt,
-			Content:   snippet.Content,
-		}, false))
+		fmt.Println(ui.RenderSnippetCard(snippet, false))
 	},
 }
 
 func init() {
 	saveCmd.Flags().StringVarP(&tags, "tags", "t", "", "Comma-separated tags")
+	saveCmd.Flags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Save even if a snippet with the same content exists")
+	saveCmd.Flags().BoolVar(&encrypt, "encrypt", false, "Encrypt the content with a passphrase")
+	saveCmd.Flags().StringArrayVarP(&saveFiles, "file", "f", nil, "Add a file to a multi-file snippet (repeatable)")
+	saveCmd.Flags().StringVar(&saveLanguage, "lang", "", "Programming language of the snippet, e.g. python")
+	saveCmd.Flags().StringVar(&saveDesc, "desc", "", "Short description of what the snippet does")
+	saveCmd.Flags().StringVar(&saveSource, "source", "", "URL the snippet came from")
+	saveCmd.Flags().StringArrayVar(&saveMeta, "meta", nil, "Set a metadata field, e.g. owner=platform (repeatable)")
+	saveCmd.Flags().StringVar(&saveIn, "in", "", "Collection to file the snippet in, e.g. infra/k8s")
 	rootCmd.AddCommand(saveCmd)
 }
@@ -7,11 +7,13 @@ import (
 
 	"github.com/charmbracelet/huh"
 	"github.com/lubasinkal/snip/internal/models"
-	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+// interactiveAllowDuplicate skips the duplicate content check
+var interactiveAllowDuplicate bool
+
 var saveInteractiveCmd = &cobra.Command{
 	Use:   "save-interactive",
 	Short: "Save a snippet using an interactive form",
@@ -23,6 +25,7 @@ var saveInteractiveCmd = &cobra.Command{
 			tagsInput   string
 			language    string
 			description string
+			sourceURL   string
 		)
 
 		// Create the interactive form
@@ -84,6 +87,11 @@ var saveInteractiveCmd = &cobra.Command{
 					Title("Tags (optional):").
 					Placeholder("e.g., 'web, api, server' (comma-separated)").
 					Value(&tagsInput),
+
+				huh.NewInput().
+					Title("Source URL (optional):").
+					Placeholder("Where did you find this snippet?").
+					Value(&sourceURL),
 			),
 		)
 
@@ -106,47 +114,72 @@ var saveInteractiveCmd = &cobra.Command{
 			}
 		}
 
-		// Add language as a tag if specified
-		if language != "" && language != "other" {
-			tags = append([]string{language}, tags...)
-		}
-
-		// Add description to content if provided
-		finalContent := content
-		if description != "" {
-			finalContent = fmt.Sprintf("// %s\n%s", description, content)
+		if language == "other" {
+			language = ""
 		}
 
 		// Create and save the snippet
 		snippet := models.Snippet{
-			Title:     title,
-			Tags:      tags,
-			CreatedAt: time.Now(),
-			Content:   finalContent,
+			Title:       title,
+			Tags:        tags,
+			CreatedAt:   time.Now(),
+			Language:    language,
+			Description: strings.TrimSpace(description),
+			SourceURL:   strings.TrimSpace(sourceURL),
+			Content:     content,
 		}
 
-		id, err := storage.SaveSnippet(snippet)
+		// Like 'snip save', don't store the same content twice by accident
+		if !interactiveAllowDuplicate {
+			duplicates, err := store.FindDuplicates(snippet)
+			if err != nil {
+				fmt.Println(ui.RenderError("Error checking for duplicates: " + err.Error()))
+				return
+			}
+			if len(duplicates) > 0 {
+				existing := duplicates[0]
+				fmt.Println(ui.RenderWarning(fmt.Sprintf("This content is already saved as snippet %d: '%s'", existing.ID, existing.Title)))
+
+				var saveAnyway bool
+				confirm := huh.NewConfirm().
+					Title("Save it anyway?").
+					Affirmative("Save").
+					Negative("Cancel").
+					Value(&saveAnyway)
+				if err := confirm.Run(); err != nil {
+					fmt.Println(ui.RenderError("Error running form: " + err.Error()))
+					return
+				}
+				if !saveAnyway {
+					fmt.Println(ui.RenderInfo("Snippet not saved."))
+					return
+				}
+			}
+		}
+
+		id, err := store.Save(snippet)
 		if err != nil {
 			fmt.Println(ui.RenderError("Error saving snippet: " + err.Error()))
 			return
 		}
 
+		// Reload to pick up the UUID and slug assigned on save
+		snippet.ID = int(id)
+		if saved, err := store.Get(snippet.ID); err == nil {
+			snippet = *saved
+		}
+
 		// Show success message
 		fmt.Println()
-		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Snippet saved with ID: %d", id)))
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Snippet saved with ID: %d (slug: %s)", id, snippet.Slug)))
 		fmt.Println()
 
 		// Show the saved snippet
-		fmt.Println(ui.RenderSnippetCard(models.Snippet{
-			ID:        int(id),
-			Title:     snippet.Title,
-			Tags:      snippet.Tags,
-			CreatedAt: snippet.CreatedAt,
-			Content:   snippet.Content,
-		}, true))
+		fmt.Println(ui.RenderSnippetCard(snippet, true))
 	},
 }
 
 func init() {
+	saveInteractiveCmd.Flags().BoolVar(&interactiveAllowDuplicate, "allow-duplicate", false, "Save even if a snippet with the same content exists")
 	rootCmd.AddCommand(saveInteractiveCmd)
 }
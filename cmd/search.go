@@ -2,34 +2,167 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/lubasinkal/snip/internal/fuzzy"
+	"github.com/lubasinkal/snip/internal/models"
 	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-var tagFilter string
+var (
+	tagFilter          string
+	searchAllLibraries bool
+	searchPinned       bool
+	searchFuzzy        bool
+)
 
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search snippets by title, tags, or content",
-	Long:  `Search through your snippets by title, tags, or content. Use --tag to filter by specific tags.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Search through your snippets by title, tags, or content. Use --tag to filter by specific tags, --pinned to only search pinned snippets and --all-libraries to search every library.
+
+With --fuzzy, each word of the query only has to appear in order, with gaps,
+as in fzf: "dkr prn" finds "docker system prune". Results are ranked by how
+well they match, preferring the title, then tags, then content, and the
+matched characters are highlighted.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		query := args[0]
+		// Fuzzy matching runs over every snippet the tag filter lets through
+		storeQuery := query
+		if searchFuzzy {
+			storeQuery = ""
+		}
+		search := func(s storage.Store) ([]models.Snippet, error) {
+			results, err := s.Search(storeQuery, tagFilter)
+			if err != nil || !searchPinned {
+				return results, err
+			}
+			var pinned []models.Snippet
+			for _, snippet := range results {
+				if snippet.Pinned {
+					pinned = append(pinned, snippet)
+				}
+			}
+			return pinned, nil
+		}
 
-		snippets, err := storage.SearchSnippets(query, tagFilter)
+		var snippets []models.Snippet
+		var err error
+		if searchAllLibraries {
+			snippets, err = queryAllLibraries(search)
+		} else {
+			snippets, err = search(store)
+		}
 		if err != nil {
 			fmt.Println(ui.RenderError("Error searching snippets: " + err.Error()))
 			return
 		}
 
+		var highlights []ui.Highlight
+		if searchFuzzy {
+			snippets, highlights = fuzzySearch(snippets, query)
+		}
+
 		// Render beautiful search results
-		fmt.Println(ui.RenderSearchResults(snippets, query, tagFilter))
+		fmt.Println(ui.RenderSearchResults(snippets, query, tagFilter, highlights))
 	},
 }
 
+// Fuzzy matches in the title count three times as much as in the content,
+// and in tags twice as much
+const (
+	fuzzyTitleWeight   = 3
+	fuzzyTagsWeight    = 2
+	fuzzyContentWeight = 1
+)
+
+// fuzzySearch keeps the snippets matching every word of query as a fuzzy
+// pattern, best match first, along with the characters each one matched
+func fuzzySearch(snippets []models.Snippet, query string) ([]models.Snippet, []ui.Highlight) {
+	type result struct {
+		snippet   models.Snippet
+		score     int
+		highlight ui.Highlight
+	}
+
+	var results []result
+	for _, snippet := range snippets {
+		tags := strings.Join(snippet.Tags, " ")
+		content := snippet.Content
+		if len(snippet.Files) > 0 {
+			content = storage.JoinFiles(snippet.Files)
+		}
+		if snippet.Encrypted {
+			content = ""
+		}
+
+		r := result{snippet: snippet}
+		matched := true
+		for _, term := range strings.Fields(query) {
+			title, inTitle := fuzzy.Find(term, snippet.Title)
+			tag, inTags := fuzzy.Find(term, tags)
+			body, inContent := fuzzy.Find(term, content)
+
+			// The field where the term matches best wins
+			best, score := "", 0
+			for _, field := range []struct {
+				name  string
+				ok    bool
+				score int
+			}{
+				{"title", inTitle, title.Score * fuzzyTitleWeight},
+				{"tags", inTags, tag.Score * fuzzyTagsWeight},
+				{"content", inContent, body.Score * fuzzyContentWeight},
+			} {
+				if field.ok && (best == "" || field.score > score) {
+					best, score = field.name, field.score
+				}
+			}
+
+			if best == "" {
+				matched = false
+				break
+			}
+			switch best {
+			case "title":
+				r.highlight.Title = append(r.highlight.Title, title.Positions...)
+			case "tags":
+				r.highlight.Tags = append(r.highlight.Tags, tag.Positions...)
+			case "content":
+				r.highlight.Content = append(r.highlight.Content, body.Positions...)
+			}
+			r.score += score
+		}
+		if matched {
+			// Terms match in any order and may share characters
+			for _, positions := range []*[]int{&r.highlight.Title, &r.highlight.Tags, &r.highlight.Content} {
+				slices.Sort(*positions)
+				*positions = slices.Compact(*positions)
+			}
+			results = append(results, r)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+
+	matches := make([]models.Snippet, 0, len(results))
+	highlights := make([]ui.Highlight, 0, len(results))
+	for _, r := range results {
+		matches = append(matches, r.snippet)
+		highlights = append(highlights, r.highlight)
+	}
+	return matches, highlights
+}
+
 func init() {
 	searchCmd.Flags().StringVarP(&tagFilter, "tag", "t", "", "Filter by tag")
+	searchCmd.Flags().BoolVar(&searchPinned, "pinned", false, "Only search pinned snippets")
+	searchCmd.Flags().BoolVarP(&searchFuzzy, "fuzzy", "z", false, "Match words as fuzzy patterns, like fzf")
+	searchCmd.Flags().BoolVarP(&searchAllLibraries, "all-libraries", "A", false, "Search every library")
 	rootCmd.AddCommand(searchCmd)
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+func TestFuzzySearchHighlights(t *testing.T) {
+	snippets := []models.Snippet{
+		{Title: "Docker prune", Tags: []string{"docker", "cleanup"}, Content: "docker system prune -f"},
+		{Title: "Go hello", Tags: []string{"go"}, Content: `fmt.Println("hello")`},
+	}
+
+	// Both terms match the title, the second before and over the first
+	found, highlights := fuzzySearch(snippets, "prune dpr")
+	if len(found) != 1 || found[0].Title != "Docker prune" {
+		t.Fatalf("found %+v", found)
+	}
+	if want := []int{0, 7, 8, 9, 10, 11}; !slices.Equal(highlights[0].Title, want) {
+		t.Errorf("title highlights = %v, want %v", highlights[0].Title, want)
+	}
+
+	found, highlights = fuzzySearch(snippets, "up cle")
+	if len(found) != 1 || found[0].Title != "Docker prune" {
+		t.Fatalf("found %+v", found)
+	}
+	if want := []int{7, 8, 9, 12, 13}; !slices.Equal(highlights[0].Tags, want) {
+		t.Errorf("tag highlights = %v, want %v", highlights[0].Tags, want)
+	}
+}
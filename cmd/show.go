@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show [id|slug|title]",
+	Short: "Show a snippet with its details and related snippets",
+	Long:  `Show a snippet's card: its content, tags, language, collection, usage and the snippets linked to it. Use 'snip cat' to print just the content.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		snippet, err := resolveSnippet(args[0])
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSnippetCard(*snippet, true))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lubasinkal/snip/internal/backup"
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+)
+
+// noAutoBackupAnnotation marks commands that take their own backup at the
+// right moment instead of the one taken before every command
+const noAutoBackupAnnotation = "snip/no-auto-backup"
+
+// autoBackup backs up the current library when its newest backup is more
+// than a day old. Problems are only reported, so a full disk never stops snip
+// from working.
+func autoBackup() {
+	due, err := backup.Due(library.Name)
+	if err != nil {
+		warnBackup(err)
+		return
+	}
+	if !due || libraryEmpty() {
+		return
+	}
+
+	if _, err := takeSnapshot("daily"); err != nil {
+		warnBackup(err)
+	}
+}
+
+// backupBefore backs up the current library right before a command changes
+// or removes snippets in ways that are hard to undo, once it has checked its
+// input and been confirmed. reason names the command in the backup's file
+// name. Like autoBackup, it only reports problems.
+func backupBefore(reason string) {
+	if _, err := takeSnapshot(reason); err != nil {
+		warnBackup(err)
+	}
+}
+
+// takeSnapshot backs up the current library, keeping as many backups as the
+// config allows. It returns nil without a backup when they are turned off
+// or the store isn't a database file.
+func takeSnapshot(reason string) (*backup.Snapshot, error) {
+	maintainer, ok := store.(storage.Maintainer)
+	if !ok || maintainer.Path() == ":memory:" {
+		return nil, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	keep := cfg.KeepBackups()
+	if keep == 0 {
+		return nil, nil
+	}
+
+	return backup.Take(maintainer, library.Name, reason, keep)
+}
+
+// libraryEmpty reports whether the current library holds no snippets, not
+// even in the trash, so there is nothing worth backing up
+func libraryEmpty() bool {
+	maintainer, ok := store.(storage.Maintainer)
+	if !ok {
+		return true
+	}
+	info, err := maintainer.Info()
+	return err == nil && info.Snippets+info.Trashed == 0
+}
+
+// warnBackup reports a failed automatic backup on stderr, keeping piped
+// output clean
+func warnBackup(err error) {
+	fmt.Fprintln(os.Stderr, ui.RenderWarning("Could not back up the database: "+err.Error()))
+}
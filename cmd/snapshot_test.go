@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lubasinkal/snip/internal/backup"
+)
+
+// snapshotReasons lists why each automatic backup of the current library
+// was taken, oldest first
+func snapshotReasons(t *testing.T) []string {
+	t.Helper()
+	snapshots, err := backup.List(library.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reasons []string
+	for _, s := range snapshots {
+		if s.Reason != "daily" {
+			reasons = append(reasons, s.Reason)
+		}
+	}
+	return reasons
+}
+
+func TestSnapshotOnlyBeforeWrites(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("SNIP_DB_PATH", filepath.Join(dir, "snippets.db"))
+	t.Setenv("EDITOR", "true")
+
+	runCommand(t, "docker system prune -f\n", "save", "Docker prune")
+
+	out := runCommand(t, "", "trash", "purge", "--older-than", "soon")
+	if got := snapshotReasons(t); len(got) != 0 {
+		t.Errorf("purge with a bad age took snapshots %q:\n%s", got, out)
+	}
+	out = runCommand(t, "n\n", "delete", "docker-prune", "--force=false")
+	if !strings.Contains(out, "Deletion cancelled.") {
+		t.Errorf("delete printed:\n%s", out)
+	}
+	if got := snapshotReasons(t); len(got) != 0 {
+		t.Errorf("cancelled delete took snapshots %q", got)
+	}
+	out = runCommand(t, "", "edit", "docker-prune")
+	if !strings.Contains(out, "No changes made.") {
+		t.Errorf("edit printed:\n%s", out)
+	}
+	if got := snapshotReasons(t); len(got) != 0 {
+		t.Errorf("edit without changes took snapshots %q", got)
+	}
+
+	runCommand(t, "y\n", "delete", "docker-prune", "--force=false")
+	runCommand(t, "", "trash", "purge", "--older-than", "", "--force")
+	if got := snapshotReasons(t); strings.Join(got, ",") != "delete,purge" {
+		t.Errorf("snapshots = %q, want delete and purge", got)
+	}
+}
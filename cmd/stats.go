@@ -8,6 +8,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/lubasinkal/snip/internal/models"
 	"github.com/lubasinkal/snip/internal/storage"
 	"github.com/lubasinkal/snip/internal/ui"
 	"github.com/spf13/cobra"
@@ -18,7 +19,7 @@ var statsCmd = &cobra.Command{
 	Short: "Show statistics about your snippets",
 	Long:  `Display beautiful statistics about your code snippet collection including counts by language, most used tags, and more.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		snippets, err := storage.ListAllSnippets()
+		snippets, err := store.List(storage.ListOptions{})
 		if err != nil {
 			fmt.Println(ui.RenderError("Error loading snippets: " + err.Error()))
 			return
@@ -47,16 +48,39 @@ var statsCmd = &cobra.Command{
 			}
 		}
 
+		// Count usage
+		totalUses := 0
+		neverUsed := 0
+		stale := 0
+		staleCutoff := time.Now().AddDate(0, 0, -staleAfterDays)
+
+		for _, snippet := range snippets {
+			totalUses += snippet.UseCount
+			if snippet.LastUsedAt.IsZero() {
+				neverUsed++
+			}
+			if snippet.LastUsedAt.Before(staleCutoff) && snippet.UpdatedAt.Before(staleCutoff) {
+				stale++
+			}
+		}
+
 		// Create basic stats box
 		basicStats := fmt.Sprintf(`📊 Overview:
   • Total Snippets: %d
   • Total Tags: %d
   • Unique Tags: %d
-  • Average Tags per Snippet: %.1f`,
+  • Average Tags per Snippet: %.1f
+  • Total Uses: %d
+  • Never Used: %d
+  • Stale (untouched for %d+ days): %d`,
 			totalSnippets,
 			totalTags,
 			len(tagCounts),
-			float64(totalTags)/float64(totalSnippets))
+			float64(totalTags)/float64(totalSnippets),
+			totalUses,
+			neverUsed,
+			staleAfterDays,
+			stale)
 
 		fmt.Println(ui.RenderBox(basicStats))
 		fmt.Println()
@@ -129,6 +153,44 @@ var statsCmd = &cobra.Command{
 			fmt.Println()
 		}
 
+		// Show most used snippets
+		var used []models.Snippet
+		for _, snippet := range snippets {
+			if snippet.UseCount > 0 {
+				used = append(used, snippet)
+			}
+		}
+
+		if len(used) > 0 {
+			fmt.Println(ui.RenderSubtitle("🔥 Most Used Snippets"))
+			fmt.Println()
+
+			sort.SliceStable(used, func(i, j int) bool {
+				return used[i].UseCount > used[j].UseCount
+			})
+
+			maxUsed := 5
+			if len(used) < maxUsed {
+				maxUsed = len(used)
+			}
+
+			for i := 0; i < maxUsed; i++ {
+				snippet := used[i]
+				fmt.Printf("  %s %d. %s\n",
+					ui.IconCopy,
+					snippet.ID,
+					lipgloss.NewStyle().Bold(true).Render(snippet.Title))
+				times := fmt.Sprintf("%d times", snippet.UseCount)
+				if snippet.UseCount == 1 {
+					times = "once"
+				}
+				fmt.Printf("     Used %s, last %s\n",
+					times,
+					lipgloss.NewStyle().Foreground(ui.TextMuted).Render(ui.FormatTimeAgo(snippet.LastUsedAt)))
+			}
+			fmt.Println()
+		}
+
 		// Show recent activity
 		fmt.Println(ui.RenderSubtitle("⏰ Recent Activity"))
 		fmt.Println()
@@ -153,7 +215,7 @@ var statsCmd = &cobra.Command{
 			}
 
 			// Format time
-			timeStr := formatTimeAgo(snippet.CreatedAt)
+			timeStr := ui.FormatTimeAgo(snippet.CreatedAt)
 			
 			fmt.Printf("  %s %d. %s%s\n", 
 				ui.IconSnippet, 
@@ -175,18 +237,16 @@ var statsCmd = &cobra.Command{
 		tips := `💡 Pro Tips:
   • Use 'snip search --tag=<tag>' to find snippets by tag
   • Use 'snip save-interactive' for a guided snippet creation
-  • Use 'snip copy <id>' to quickly copy snippets to clipboard`
+  • Use 'snip copy <id>' to quickly copy snippets to clipboard
+  • Use 'snip list --sort=uses' to see your most used snippets first`
 		
 		fmt.Println(ui.RenderBox(tips))
 	},
 }
 
-// Helper function for time formatting (reused from table.go)
-func formatTimeAgo(t time.Time) string {
-	// This function is already implemented in table.go
-	// For now, let's use a simple implementation
-	return t.Format("Jan 2, 2006")
-}
+// staleAfterDays is how long a snippet can go unused and unchanged before
+// stats reports it as stale
+const staleAfterDays = 90
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
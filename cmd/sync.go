@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/gitsync"
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var syncRemote string
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the library with other machines through git",
+	Long: `Write the library into a local git repository, one file per snippet, commit
+the changes, merge what other machines pushed to the remote and push the result.
+
+Set the remote once with --remote; any git URL works, including a bare
+repository on disk or a shared drive. Without a remote, changes are only
+committed locally.
+
+When the same snippet was changed on two machines, the newer version is kept
+and the other is saved as the revision before it, so 'snip history' and
+'snip diff' show both. Snippets deleted on another machine are moved to the
+trash here.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error reading config: " + err.Error()))
+			return
+		}
+
+		if syncRemote != "" {
+			cfg.SetSyncRemote(library.Name, syncRemote)
+			if err := cfg.Save(); err != nil {
+				fmt.Println(ui.RenderError("Error saving config: " + err.Error()))
+				return
+			}
+		}
+		remote := cfg.SyncRemote(library.Name)
+
+		repo, err := gitsync.Open(gitsync.Dir(library.Name))
+		if err != nil {
+			fmt.Println(ui.RenderError("Error opening sync repository: " + err.Error()))
+			return
+		}
+		if remote != "" && repo.Remote() != remote {
+			if err := repo.SetRemote(remote); err != nil {
+				fmt.Println(ui.RenderError("Error setting remote: " + err.Error()))
+				return
+			}
+		}
+
+		snippets, err := store.List(storage.ListOptions{})
+		if err != nil {
+			fmt.Println(ui.RenderError("Error listing snippets: " + err.Error()))
+			return
+		}
+		if err := repo.Export(snippets); err != nil {
+			fmt.Println(ui.RenderError("Error writing snippets: " + err.Error()))
+			return
+		}
+
+		host, _ := os.Hostname()
+		committed, err := repo.Commit(fmt.Sprintf("Update library '%s' from %s", library.Name, host))
+		if err != nil {
+			fmt.Println(ui.RenderError("Error committing changes: " + err.Error()))
+			return
+		}
+
+		if remote == "" {
+			if committed {
+				fmt.Println(ui.RenderSuccess("Committed changes to " + repo.Path()))
+			} else {
+				fmt.Println(ui.RenderInfo("No changes since the last sync"))
+			}
+			fmt.Println(ui.RenderInfo("Set a remote to share the library: snip sync --remote <git-url>"))
+			return
+		}
+
+		before := repo.Head()
+		conflicts, err := repo.Pull()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error pulling from " + remote + ": " + err.Error()))
+			return
+		}
+
+		merged, duplicates, err := repo.Snippets()
+		if err != nil {
+			repo.Reset(before)
+			fmt.Println(ui.RenderError("Error reading merged snippets: " + err.Error()))
+			return
+		}
+		conflicts = append(conflicts, duplicates...)
+
+		// Nothing pulled means the merged snippets are the ones just exported
+		if repo.Head() != before || len(conflicts) > 0 {
+			backupBefore("sync")
+		}
+
+		var changes *gitsync.Changes
+		err = inTransaction(func(tx storage.Store) error {
+			changes, err = gitsync.Apply(tx, merged, conflicts)
+			return err
+		})
+		if err != nil {
+			// Forget the merge, so the next sync doesn't push the library's
+			// older state over it
+			repo.Reset(before)
+			fmt.Println(ui.RenderError("Error updating the library, nothing was changed: " + err.Error()))
+			return
+		}
+
+		if err := repo.Push(); err != nil {
+			fmt.Println(ui.RenderError("Error pushing to " + remote + ": " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Synced library '%s' with %s", library.Name, remote)))
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("%d added, %d updated, %d moved, %d restored, %d moved to the trash",
+			changes.Added, changes.Updated, changes.Moved, changes.Restored, changes.Deleted)))
+		for _, c := range conflicts {
+			fmt.Println(ui.RenderWarning(fmt.Sprintf("'%s' was changed on two machines; kept the newer version. See the other with 'snip diff %d'",
+				conflictTitle(c.Kept, c.Lost), c.SnippetID)))
+		}
+	},
+}
+
+// conflictTitle names a conflicted snippet by its kept title, noting the
+// other one when they differ
+func conflictTitle(kept, lost models.Snippet) string {
+	if kept.Title == lost.Title {
+		return kept.Title
+	}
+	return kept.Title + "' / '" + lost.Title
+}
+
+func init() {
+	syncCmd.Flags().StringVar(&syncRemote, "remote", "", "Git URL to sync with, remembered for the current library")
+	rootCmd.AddCommand(syncCmd)
+}
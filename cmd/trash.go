@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeOlderThan string
+	forcePurge     bool
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List deleted snippets",
+	Long:  `Show snippets that have been deleted. Deleted snippets stay in the trash until purged and can be brought back with 'snip restore'.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snippets, err := store.Trash()
+		if err != nil {
+			fmt.Println(ui.RenderError("Error listing trash: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderTitle(ui.IconDelete + " Trash"))
+		fmt.Println()
+		fmt.Println(ui.RenderTrashTable(snippets))
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:         "purge",
+	Short:       "Permanently remove snippets from the trash",
+	Long:        `Permanently remove trashed snippets and their history. Use --older-than (e.g. 30d, 2w, 12h) to keep recently deleted snippets.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{noAutoBackupAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		before := time.Now()
+		description := "all snippets in the trash"
+		if purgeOlderThan != "" {
+			age, err := parseAge(purgeOlderThan)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			before = before.Add(-age)
+			description = fmt.Sprintf("snippets deleted more than %s ago", purgeOlderThan)
+		}
+
+		if !forcePurge {
+			fmt.Printf("%s Permanently remove %s? This cannot be undone. [y/N]: ", ui.IconDelete, description)
+
+			reader := bufio.NewReader(os.Stdin)
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				fmt.Println(ui.RenderError("Error reading input: " + err.Error()))
+				return
+			}
+
+			response = strings.ToLower(strings.TrimSpace(response))
+			if response != "y" && response != "yes" {
+				fmt.Println(ui.RenderInfo("Purge cancelled."))
+				return
+			}
+		}
+
+		backupBefore("purge")
+		purged, err := store.Purge(before)
+		if err != nil {
+			fmt.Println(ui.RenderError("Error purging trash: " + err.Error()))
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Permanently removed %d snippet(s)", purged)))
+	},
+}
+
+// parseAge parses a duration such as 30d, 2w or 12h. Days and weeks are
+// accepted on top of the units time.ParseDuration understands.
+func parseAge(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid age '%s'. Use a number followed by d, w, h or m (e.g. 30d)", value)
+
+	if unit := value[len(value)-1]; unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return 0, invalid
+		}
+		days := n
+		if unit == 'w' {
+			days *= 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, invalid
+	}
+	return age, nil
+}
+
+func init() {
+	trashPurgeCmd.Flags().StringVar(&purgeOlderThan, "older-than", "", "Only purge snippets deleted longer ago than this (e.g. 30d)")
+	trashPurgeCmd.Flags().BoolVarP(&forcePurge, "force", "f", false, "Skip confirmation prompt")
+	trashCmd.AddCommand(trashPurgeCmd)
+	rootCmd.AddCommand(trashCmd)
+}
@@ -15,9 +15,10 @@ const (
 )
 
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Show version information",
-	Long:  `Display version information about snip including build details and system info.`,
+	Use:         "version",
+	Short:       "Show version information",
+	Long:        `Display version information about snip including build details and system info.`,
+	Annotations: map[string]string{noStoreAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show beautiful version header
 		fmt.Println(ui.RenderTitle(ui.IconRocket + " snip — Terminal Code Snippet Manager"))
@@ -0,0 +1,149 @@
+// Package backup manages the automatic snapshots snip takes of each
+// library's database before destructive commands and once a day.
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// DailyInterval is how long snip waits between routine snapshots
+const DailyInterval = 24 * time.Hour
+
+// timestampLayout sorts lexically in time order and is safe in file names
+const timestampLayout = "20060102T150405.000Z"
+
+// Snapshot is one automatic backup of a library
+type Snapshot struct {
+	Path      string
+	Library   string
+	CreatedAt time.Time
+	// Reason is the command that triggered the snapshot, or "daily"
+	Reason string
+	Size   int64
+}
+
+// Dir returns the directory snapshots are written to
+func Dir() string {
+	return filepath.Join(config.Dir(), "backups")
+}
+
+// snapshotPattern matches the file names of a library's snapshots. Library
+// names can contain dashes, but the timestamp always follows directly, so
+// "work" never matches the snapshots of "work-old".
+func snapshotPattern(library string) *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(library) + `-(\d{8}T\d{6}\.\d{3}Z)-([a-z-]+)\.db$`)
+}
+
+// List returns the snapshots of a library, oldest first
+func List(library string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := snapshotPattern(library)
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		m := pattern.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			continue
+		}
+
+		createdAt, err := time.Parse(timestampLayout, m[1])
+		if err != nil {
+			continue
+		}
+
+		snapshot := Snapshot{
+			Path:      filepath.Join(Dir(), entry.Name()),
+			Library:   library,
+			CreatedAt: createdAt,
+			Reason:    m[2],
+		}
+		if info, err := entry.Info(); err == nil {
+			snapshot.Size = info.Size()
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Latest returns the most recent snapshot of a library
+func Latest(library string) (*Snapshot, error) {
+	snapshots, err := List(library)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("library '%s' has no automatic backups in %s", library, Dir())
+	}
+	return &snapshots[len(snapshots)-1], nil
+}
+
+// Take writes a snapshot of a library's database and then removes all but
+// the newest keep snapshots of that library
+func Take(m storage.Maintainer, library string, reason string, keep int) (*Snapshot, error) {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	path := filepath.Join(Dir(), fmt.Sprintf("%s-%s-%s.db", library, now.Format(timestampLayout), reason))
+	if err := m.Backup(path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	if err := Prune(library, keep); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Path: path, Library: library, CreatedAt: now, Reason: reason}
+	if info, err := os.Stat(path); err == nil {
+		snapshot.Size = info.Size()
+	}
+	return snapshot, nil
+}
+
+// Due reports whether a library's newest snapshot is older than DailyInterval
+func Due(library string) (bool, error) {
+	snapshots, err := List(library)
+	if err != nil {
+		return false, err
+	}
+	if len(snapshots) == 0 {
+		return true, nil
+	}
+	return time.Since(snapshots[len(snapshots)-1].CreatedAt) >= DailyInterval, nil
+}
+
+// Prune removes all but the newest keep snapshots of a library
+func Prune(library string, keep int) error {
+	snapshots, err := List(library)
+	if err != nil {
+		return err
+	}
+
+	for len(snapshots) > keep {
+		if err := os.Remove(snapshots[0].Path); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
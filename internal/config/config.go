@@ -0,0 +1,261 @@
+// Package config reads and writes snip's settings file, config.json, which
+// lives next to the default database.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// DefaultLibrary is the name of the library stored at storage.DBPath. It
+// always exists and is used until another library is selected.
+const DefaultLibrary = "default"
+
+// DefaultBackupKeep is how many automatic backups are kept per library
+// unless configured otherwise
+const DefaultBackupKeep = 10
+
+// libraryNamePattern restricts library names to something safe to use as a
+// file name and to type on the command line
+var libraryNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Library is a named snippet collection with its own database or directory
+type Library struct {
+	Name string `json:"-"`
+	Path string `json:"path"`
+	// Backend is storage.BackendSQLite, the default, or storage.BackendFiles
+	Backend string `json:"backend,omitempty"`
+}
+
+// Open opens the library's store
+func (l Library) Open() (storage.Store, error) {
+	return storage.OpenBackend(l.Backend, l.Path)
+}
+
+// OpenUnmigrated opens the library's store without applying pending schema
+// migrations
+func (l Library) OpenUnmigrated() (storage.Store, error) {
+	return storage.OpenBackendUnmigrated(l.Backend, l.Path)
+}
+
+// Config holds every persisted setting
+type Config struct {
+	// CurrentLibrary is the library commands use when --library isn't given
+	CurrentLibrary string             `json:"current_library,omitempty"`
+	Libraries      map[string]Library `json:"libraries,omitempty"`
+	// BackupKeep is how many automatic backups to keep per library. Zero
+	// turns automatic backups off; unset means DefaultBackupKeep.
+	BackupKeep *int `json:"backup_keep,omitempty"`
+	// SyncRemotes maps library names to the git remote snip sync uses
+	SyncRemotes map[string]string `json:"sync_remotes,omitempty"`
+}
+
+// Dir returns the directory holding the config file and library databases
+func Dir() string {
+	return filepath.Dir(storage.DBPath())
+}
+
+// Path returns the path to the config file
+func Path() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+// Load reads the config file. A missing file yields an empty config.
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", Path(), err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file, creating its directory if needed
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated config
+	tmp := Path() + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, Path())
+}
+
+// Library returns the library with the given name
+func (c *Config) Library(name string) (Library, error) {
+	if name == DefaultLibrary {
+		return c.defaultLibrary(), nil
+	}
+
+	lib, ok := c.Libraries[name]
+	if !ok {
+		return Library{}, fmt.Errorf("library '%s' does not exist. Create it with 'snip library create %s'", name, name)
+	}
+	lib.Name = name
+	return lib, nil
+}
+
+// Current returns the library commands use by default
+func (c *Config) Current() (Library, error) {
+	if c.CurrentLibrary == "" {
+		return c.Library(DefaultLibrary)
+	}
+	return c.Library(c.CurrentLibrary)
+}
+
+// AllLibraries returns every library, the default one first and the rest by name
+func (c *Config) AllLibraries() []Library {
+	var names []string
+	for name := range c.Libraries {
+		if name != DefaultLibrary {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	libraries := []Library{c.defaultLibrary()}
+	for _, name := range names {
+		lib := c.Libraries[name]
+		lib.Name = name
+		libraries = append(libraries, lib)
+	}
+	return libraries
+}
+
+// defaultLibrary returns the default library, stored at storage.DBPath
+// unless snip init gave it another backend
+func (c *Config) defaultLibrary() Library {
+	if lib, ok := c.Libraries[DefaultLibrary]; ok {
+		lib.Name = DefaultLibrary
+		return lib
+	}
+	return Library{Name: DefaultLibrary, Path: storage.DBPath()}
+}
+
+// SetDefaultBackend changes where the default library is stored. An empty
+// path means the usual location for the backend.
+func (c *Config) SetDefaultBackend(backend string, path string) (Library, error) {
+	if err := validateBackend(backend); err != nil {
+		return Library{}, err
+	}
+
+	switch {
+	case path == "" && backend == storage.BackendFiles:
+		path = filepath.Join(Dir(), "snippets")
+	case path == "":
+		path = storage.DBPath()
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Library{}, err
+	}
+
+	if c.Libraries == nil {
+		c.Libraries = make(map[string]Library)
+	}
+	lib := Library{Name: DefaultLibrary, Path: path, Backend: backend}
+	if backend == storage.BackendSQLite && path == storage.DBPath() {
+		delete(c.Libraries, DefaultLibrary)
+	} else {
+		c.Libraries[DefaultLibrary] = lib
+	}
+	return lib, nil
+}
+
+// validateBackend rejects backends storage.OpenBackend doesn't know
+func validateBackend(backend string) error {
+	switch backend {
+	case storage.BackendSQLite, storage.BackendFiles:
+		return nil
+	}
+	return fmt.Errorf("unknown storage backend '%s'. Use '%s' or '%s'", backend, storage.BackendSQLite, storage.BackendFiles)
+}
+
+// AddLibrary registers a new library stored with backend. An empty path
+// stores it in the libraries directory under Dir.
+func (c *Config) AddLibrary(name string, path string, backend string) (Library, error) {
+	if !libraryNamePattern.MatchString(name) {
+		return Library{}, fmt.Errorf("invalid library name '%s'. Use letters, digits, '-' and '_'", name)
+	}
+	if _, exists := c.Libraries[name]; exists || name == DefaultLibrary {
+		return Library{}, fmt.Errorf("library '%s' already exists", name)
+	}
+
+	if err := validateBackend(backend); err != nil {
+		return Library{}, err
+	}
+
+	switch {
+	case path == "" && backend == storage.BackendFiles:
+		path = filepath.Join(Dir(), "libraries", name)
+	case path == "":
+		path = filepath.Join(Dir(), "libraries", name+".db")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Library{}, err
+	}
+
+	if c.Libraries == nil {
+		c.Libraries = make(map[string]Library)
+	}
+	lib := Library{Name: name, Path: path}
+	if backend != storage.BackendSQLite {
+		lib.Backend = backend
+	}
+	c.Libraries[name] = lib
+	return lib, nil
+}
+
+// KeepBackups returns how many automatic backups to keep per library
+func (c *Config) KeepBackups() int {
+	if c.BackupKeep == nil {
+		return DefaultBackupKeep
+	}
+	return *c.BackupKeep
+}
+
+// SetKeepBackups sets how many automatic backups to keep per library
+func (c *Config) SetKeepBackups(n int) error {
+	if n < 0 {
+		return fmt.Errorf("the number of backups to keep cannot be negative")
+	}
+	c.BackupKeep = &n
+	return nil
+}
+
+// SyncRemote returns the git remote a library syncs with, or ""
+func (c *Config) SyncRemote(library string) string {
+	return c.SyncRemotes[library]
+}
+
+// SetSyncRemote sets the git remote a library syncs with
+func (c *Config) SetSyncRemote(library string, remote string) {
+	if c.SyncRemotes == nil {
+		c.SyncRemotes = make(map[string]string)
+	}
+	c.SyncRemotes[library] = remote
+}
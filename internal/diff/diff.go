@@ -0,0 +1,175 @@
+// Package diff computes line-based differences between two texts and groups
+// them into unified diff hunks.
+package diff
+
+import "strings"
+
+// Kind says whether a line is shared by both texts or only in one of them
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Line is a single line of a diff
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Hunk is a run of changes with surrounding context, as in a unified diff.
+// Line numbers are 1-based.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Lines compares a and b line by line and returns the edit script that turns
+// a into b
+func Lines(a, b string) []Line {
+	oldLines := splitLines(a)
+	newLines := splitLines(b)
+
+	// Common prefix and suffix never need the quadratic table below
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var result []Line
+	for _, text := range oldLines[:prefix] {
+		result = append(result, Line{Equal, text})
+	}
+	result = append(result, lcs(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, text := range oldLines[len(oldLines)-suffix:] {
+		result = append(result, Line{Equal, text})
+	}
+
+	return result
+}
+
+// lcs diffs two line slices using a longest-common-subsequence table
+func lcs(a, b []string) []Line {
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var result []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Equal, a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			result = append(result, Line{Delete, a[i]})
+			i++
+		default:
+			result = append(result, Line{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, Line{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Insert, b[j]})
+	}
+
+	return result
+}
+
+// Hunks groups an edit script into hunks with up to context unchanged lines
+// around each change. It returns nil when the texts are identical.
+func Hunks(lines []Line, context int) []Hunk {
+	// Line numbers in the old and new text at each position of the script
+	oldAt := make([]int, len(lines))
+	newAt := make([]int, len(lines))
+	oldLine, newLine := 1, 1
+	var changes []int
+	for i, line := range lines {
+		oldAt[i], newAt[i] = oldLine, newLine
+		if line.Kind != Insert {
+			oldLine++
+		}
+		if line.Kind != Delete {
+			newLine++
+		}
+		if line.Kind != Equal {
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []Hunk
+	for len(changes) > 0 {
+		// Changes closer together than two contexts share a hunk
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*context {
+			last++
+		}
+
+		start := max(changes[0]-context, 0)
+		end := min(changes[last]+context+1, len(lines))
+		hunks = append(hunks, countHunk(Hunk{
+			OldStart: oldAt[start],
+			NewStart: newAt[start],
+			Lines:    lines[start:end],
+		}))
+
+		changes = changes[last+1:]
+	}
+
+	return hunks
+}
+
+// countHunk fills in the old and new line counts of a hunk
+func countHunk(h Hunk) Hunk {
+	h.OldLines, h.NewLines = 0, 0
+	for _, line := range h.Lines {
+		if line.Kind != Insert {
+			h.OldLines++
+		}
+		if line.Kind != Delete {
+			h.NewLines++
+		}
+	}
+
+	// Like diff -u, an empty side points at the line before the hunk
+	if h.OldLines == 0 {
+		h.OldStart--
+	}
+	if h.NewLines == 0 {
+		h.NewStart--
+	}
+	return h
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,127 @@
+// Package fuzzy matches patterns against text as subsequences and scores the
+// matches the way fzf does, so "dkr prn" finds "docker system prune".
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scores for matched characters and the gaps between them
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	// Matches at the start of a word count for more, so "gc" prefers
+	// "git commit" to "magic"
+	bonusBoundary = scoreMatch / 2
+	bonusCamel    = bonusBoundary - 1
+	// Runs of consecutive matches are worth at least this much per character
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+	// The bonus of the first pattern character counts double
+	bonusFirstCharMultiplier = 2
+)
+
+// Match is a successful match of a pattern in a text
+type Match struct {
+	Score int
+	// Positions are the indexes of the matched runes in the text, ascending
+	Positions []int
+}
+
+// Find matches pattern against text as a subsequence, ignoring case. Of the
+// possible matches it picks one in the shortest stretch of text ending at
+// the first place the whole pattern has been seen. An empty pattern matches
+// any text with a score of zero.
+func Find(pattern string, text string) (Match, bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return Match{}, true
+	}
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// A few runes change length when lowercased; compare rune by rune
+		lower = make([]rune, len(runes))
+		for i, r := range runes {
+			lower[i] = unicode.ToLower(r)
+		}
+	}
+
+	// Forward pass: where the pattern is first complete
+	end, pi := -1, 0
+	for i, r := range lower {
+		if r == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return Match{}, false
+	}
+
+	// Backward pass: the latest start that still completes the pattern
+	start := end
+	for pi = len(p) - 1; start >= 0; start-- {
+		if lower[start] == p[pi] {
+			pi--
+			if pi < 0 {
+				break
+			}
+		}
+	}
+
+	var m Match
+	pi = 0
+	inGap, consecutive := false, false
+	for i := start; i <= end && pi < len(p); i++ {
+		if lower[i] != p[pi] {
+			if inGap {
+				m.Score += scoreGapExtension
+			} else {
+				m.Score += scoreGapStart
+			}
+			inGap, consecutive = true, false
+			continue
+		}
+
+		bonus := bonusAt(runes, i)
+		switch {
+		case pi == 0:
+			bonus *= bonusFirstCharMultiplier
+		case consecutive:
+			bonus = max(bonus, bonusConsecutive)
+		}
+		m.Score += scoreMatch + bonus
+		m.Positions = append(m.Positions, i)
+		inGap, consecutive = false, true
+		pi++
+	}
+	return m, true
+}
+
+// bonusAt rewards a match at the start of a word, after a separator, or at
+// a camelCase or letter-to-digit transition
+func bonusAt(text []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, cur := text[i-1], text[i]
+	switch {
+	case !isWordRune(prev) && isWordRune(cur):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur),
+		!unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	default:
+		return 0
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,88 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	cases := []struct {
+		name      string
+		pattern   string
+		text      string
+		ok        bool
+		score     int
+		positions []int
+	}{
+		{"empty pattern", "", "anything", true, 0, nil},
+		{"exact", "abc", "abc", true, 72, []int{0, 1, 2}},
+		{"ignores case", "DOCK", "docker", true, 92, []int{0, 1, 2, 3}},
+		{"subsequence across words", "dp", "docker prune", true, 48, []int{0, 7}},
+		{"inside a word", "o", "foo", true, 16, []int{1}},
+		{"consecutive inside a word", "oo", "foo", true, 36, []int{1, 2}},
+		{"camel case", "b", "fooBar", true, 30, []int{3}},
+		{"digit after a letter", "2", "v2", true, 30, []int{1}},
+		{"after a separator", "ab", "a-b", true, 53, []int{0, 2}},
+		{"shortest stretch", "ab", "a a b", true, 53, []int{2, 4}},
+		{"missing character", "xyz", "docker", false, 0, nil},
+		{"out of order", "ba", "ab", false, 0, nil},
+		{"longer than text", "aa", "a", false, 0, nil},
+		{"empty text", "a", "", false, 0, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, ok := Find(c.pattern, c.text)
+			if ok != c.ok {
+				t.Fatalf("Find(%q, %q) matched = %v, want %v", c.pattern, c.text, ok, c.ok)
+			}
+			if m.Score != c.score || !slices.Equal(m.Positions, c.positions) {
+				t.Errorf("Find(%q, %q) = %d at %v, want %d at %v", c.pattern, c.text, m.Score, m.Positions, c.score, c.positions)
+			}
+		})
+	}
+}
+
+func TestFindPrefersWordStarts(t *testing.T) {
+	for _, c := range []struct{ pattern, better, worse string }{
+		{"gc", "git commit", "magic"},
+		{"gco", "git checkout", "gecko"},
+		{"sb", "snipBackup", "sebum"},
+		{"log", "log.txt", "catalog"},
+	} {
+		better, ok := Find(c.pattern, c.better)
+		if !ok {
+			t.Fatalf("%q doesn't match %q", c.pattern, c.better)
+		}
+		worse, ok := Find(c.pattern, c.worse)
+		if !ok {
+			t.Fatalf("%q doesn't match %q", c.pattern, c.worse)
+		}
+		if better.Score <= worse.Score {
+			t.Errorf("%q scores %d in %q and %d in %q", c.pattern, better.Score, c.better, worse.Score, c.worse)
+		}
+	}
+}
+
+func TestBonusAt(t *testing.T) {
+	cases := []struct {
+		text string
+		i    int
+		want int
+	}{
+		{"docker prune", 0, bonusBoundary},
+		{"docker prune", 7, bonusBoundary},
+		{"docker prune", 1, 0},
+		{"a.b", 2, bonusBoundary},
+		{"a.b", 1, 0},
+		{"fooBar", 3, bonusCamel},
+		{"FOO", 1, 0},
+		{"v2", 1, bonusCamel},
+		{"12", 1, 0},
+		{"über ärger", 5, bonusBoundary},
+	}
+	for _, c := range cases {
+		if got := bonusAt([]rune(c.text), c.i); got != c.want {
+			t.Errorf("bonusAt(%q, %d) = %d, want %d", c.text, c.i, got, c.want)
+		}
+	}
+}
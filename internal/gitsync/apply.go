@@ -0,0 +1,216 @@
+package gitsync
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// Changes counts what Apply changed in the library
+type Changes struct {
+	Added    int
+	Updated  int
+	Moved    int
+	Restored int
+	Deleted  int
+}
+
+// Apply makes the library match the merged snippets. Snippets missing from
+// them are moved to the trash, so a deletion on another machine can still
+// be undone here. For each conflict, the losing version is saved first and
+// the kept one over it, leaving the loser as the previous revision.
+func Apply(store storage.Store, merged []models.Snippet, conflicts []Conflict) (*Changes, error) {
+	live, err := store.List(storage.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	trash, err := store.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]models.Snippet)
+	trashed := make(map[string]bool)
+	for _, s := range live {
+		local[s.UUID] = s
+	}
+	for _, s := range trash {
+		local[s.UUID] = s
+		trashed[s.UUID] = true
+	}
+
+	lost := make(map[string]*Conflict)
+	for i := range conflicts {
+		lost[conflicts[i].Kept.UUID] = &conflicts[i]
+	}
+
+	changes := &Changes{}
+	ids := make(map[string]int)
+	counted := make(map[string]bool)
+	for _, s := range merged {
+		conflict := lost[s.UUID]
+
+		existing, ok := local[s.UUID]
+		if !ok {
+			first := s
+			if conflict != nil {
+				first = conflict.Lost
+				first.UUID, first.Slug, first.CreatedAt = s.UUID, s.Slug, s.CreatedAt
+			}
+			id, err := store.Save(first)
+			if err != nil {
+				return nil, err
+			}
+			existing = first
+			existing.ID = int(id)
+			changes.Added++
+		} else if trashed[s.UUID] {
+			if err := store.Restore(existing.ID); err != nil {
+				return nil, err
+			}
+			changes.Restored++
+		}
+
+		id := existing.ID
+		ids[s.UUID] = id
+		// Updates leave the collection, pin and metadata alone, so they
+		// are compared with the library rather than the losing version
+		current := existing
+		if conflict != nil {
+			conflict.SnippetID = id
+			if ok && changed(existing, conflict.Lost) {
+				if err := update(store, id, conflict.Lost); err != nil {
+					return nil, err
+				}
+			}
+			existing = conflict.Lost
+		}
+
+		updated := false
+		if changed(existing, s) {
+			if err := update(store, id, s); err != nil {
+				return nil, err
+			}
+			updated = true
+		}
+		if current.Pinned != s.Pinned {
+			if err := store.Pin(id, s.Pinned); err != nil {
+				return nil, err
+			}
+			updated = true
+		}
+		if !maps.Equal(current.Meta, s.Meta) {
+			if err := applyMeta(store, id, current.Meta, s.Meta); err != nil {
+				return nil, err
+			}
+			updated = true
+		}
+		if ok && updated {
+			changes.Updated++
+			counted[s.UUID] = true
+		}
+
+		if current.Collection != s.Collection {
+			if err := store.Move(id, s.Collection); err != nil {
+				return nil, err
+			}
+			if ok {
+				changes.Moved++
+			}
+		}
+	}
+
+	// Links go last, once every snippet they point to is in the library
+	for _, s := range merged {
+		existing, ok := local[s.UUID]
+		linked, err := applyLinks(store, ids[s.UUID], existing.Links, s.Links, ids)
+		if err != nil {
+			return nil, err
+		}
+		if ok && linked && !counted[s.UUID] {
+			changes.Updated++
+		}
+	}
+
+	for _, s := range live {
+		if _, ok := ids[s.UUID]; !ok {
+			if err := store.Delete(s.ID); err != nil {
+				return nil, err
+			}
+			changes.Deleted++
+		}
+	}
+	return changes, nil
+}
+
+// applyMeta changes the metadata of the snippet with the given ID from have
+// to want
+func applyMeta(store storage.Store, id int, have, want map[string]string) error {
+	for key := range have {
+		if _, ok := want[key]; !ok {
+			if err := store.UnsetMeta(id, key); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range want {
+		if have[key] != value {
+			if err := store.SetMeta(id, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyLinks changes the links of the snippet with the given ID from have
+// to want, reporting whether anything changed. ids maps UUIDs to IDs in the
+// library.
+func applyLinks(store storage.Store, id int, have, want []models.Link, ids map[string]int) (bool, error) {
+	wanted := make(map[string]string)
+	for _, link := range want {
+		wanted[link.UUID] = link.Rel
+	}
+	had := make(map[string]string)
+	modified := false
+	for _, link := range have {
+		had[link.UUID] = link.Rel
+		if _, ok := wanted[link.UUID]; !ok {
+			if err := store.Unlink(id, link.ID); err != nil {
+				return false, err
+			}
+			modified = true
+		}
+	}
+	for _, link := range want {
+		target, ok := ids[link.UUID]
+		if !ok || had[link.UUID] == link.Rel {
+			continue
+		}
+		if err := store.Link(id, target, link.Rel); err != nil {
+			return false, err
+		}
+		modified = true
+	}
+	return modified, nil
+}
+
+// update saves the content of s over the snippet with the given ID
+func update(store storage.Store, id int, s models.Snippet) error {
+	s.ID = id
+	return store.Update(s)
+}
+
+// changed reports whether anything synced differs between two versions
+func changed(a, b models.Snippet) bool {
+	return a.Title != b.Title ||
+		!slices.Equal(storage.CleanTags(a.Tags), storage.CleanTags(b.Tags)) ||
+		a.Encrypted != b.Encrypted ||
+		a.Language != b.Language ||
+		a.Description != b.Description ||
+		a.SourceURL != b.SourceURL ||
+		a.Content != b.Content ||
+		!slices.Equal(a.Files, b.Files)
+}
@@ -0,0 +1,419 @@
+// Package gitsync keeps a library in step between machines through a git
+// repository holding one file per snippet. Each machine serializes its
+// library into its own clone, commits, merges what the others pushed and
+// loads the result back into the library.
+package gitsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/config"
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// Branch is the branch every machine commits to and pulls from
+const Branch = "main"
+
+// snippetsDir holds one file per snippet, named after its UUID
+const snippetsDir = "snippets"
+
+// metaFile describes the layout of the repository, so a newer snip can
+// change it without older ones misreading it
+const metaFile = "snip.json"
+
+// formatVersion is the layout written by this version of snip
+const formatVersion = 1
+
+// ErrNoGit is returned when git isn't installed
+var ErrNoGit = errors.New("snip sync needs git, which was not found in PATH")
+
+// repoMeta is the content of metaFile
+type repoMeta struct {
+	Format int `json:"format"`
+}
+
+// Repo is a library's local sync repository
+type Repo struct {
+	dir string
+	// identity is passed to git commands that commit, for users who never
+	// configured a git name and email
+	identity []string
+}
+
+// Conflict is a snippet changed on both sides of a merge. The newer version
+// is kept; the other one is recorded in the snippet's history.
+type Conflict struct {
+	Kept models.Snippet
+	Lost models.Snippet
+	// SnippetID is the snippet's ID in the library, set by Apply
+	SnippetID int
+}
+
+// Dir returns where the sync repository of a library is kept
+func Dir(library string) string {
+	return filepath.Join(config.Dir(), "sync", library)
+}
+
+// Open opens the sync repository in dir, creating it if needed
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNoGit
+	}
+	if err := os.MkdirAll(filepath.Join(dir, snippetsDir), 0755); err != nil {
+		return nil, err
+	}
+
+	repo := &Repo{dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if _, err := repo.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+		if _, err := repo.git("symbolic-ref", "HEAD", "refs/heads/"+Branch); err != nil {
+			return nil, err
+		}
+	}
+
+	if email, _ := repo.git("config", "user.email"); email == "" {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "localhost"
+		}
+		repo.identity = []string{"-c", "user.name=snip", "-c", "user.email=snip@" + host}
+	}
+
+	if err := repo.checkMeta(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Path returns the directory of the repository
+func (r *Repo) Path() string {
+	return r.dir
+}
+
+// checkMeta writes metaFile, or refuses a layout newer than formatVersion
+func (r *Repo) checkMeta() error {
+	path := filepath.Join(r.dir, metaFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var meta repoMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+		if meta.Format > formatVersion {
+			return fmt.Errorf("the sync repository was written by a newer version of snip (format %d); please upgrade", meta.Format)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err = json.MarshalIndent(repoMeta{Format: formatVersion}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// git runs a git command in the repository and returns its trimmed output
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// commit runs git commit with the fallback identity
+func (r *Repo) commit(args ...string) error {
+	_, err := r.git(append(append(append([]string(nil), r.identity...), "commit", "--quiet"), args...)...)
+	return err
+}
+
+// Remote returns the URL of the remote, or "" when none is set
+func (r *Repo) Remote() string {
+	url, _ := r.git("remote", "get-url", "origin")
+	return url
+}
+
+// SetRemote points the repository at the remote to sync with
+func (r *Repo) SetRemote(url string) error {
+	if r.Remote() == "" {
+		_, err := r.git("remote", "add", "origin", url)
+		return err
+	}
+	_, err := r.git("remote", "set-url", "origin", url)
+	return err
+}
+
+// fileUUID returns the UUID a snippet file is named after
+func fileUUID(name string) string {
+	uuid, _, _ := strings.Cut(name, ".")
+	return uuid
+}
+
+// Export makes the repository's working tree hold exactly the given
+// snippets. A snippet keeps its file name from earlier syncs, so changing
+// its tags never renames the file.
+func (r *Repo) Export(snippets []models.Snippet) error {
+	dir := filepath.Join(r.dir, snippetsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		uuid := fileUUID(entry.Name())
+		if _, dup := existing[uuid]; dup {
+			// Left over from a merge; Snippets already reported it
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+			continue
+		}
+		existing[uuid] = entry.Name()
+	}
+
+	for _, s := range snippets {
+		ext, data, err := storage.EncodeSnippet(s)
+		if err != nil {
+			return fmt.Errorf("snippet %d: %w", s.ID, err)
+		}
+		name, ok := existing[s.UUID]
+		if !ok {
+			name = s.UUID + ext
+		}
+		delete(existing, s.UUID)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Whatever is left was deleted from the library
+	for _, name := range existing {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit records every change in the working tree. It reports whether there
+// was anything to commit.
+func (r *Repo) Commit(message string) (bool, error) {
+	if _, err := r.git("add", "--all"); err != nil {
+		return false, err
+	}
+	status, err := r.git("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if status == "" {
+		return false, nil
+	}
+	return true, r.commit("-m", message)
+}
+
+// Pull fetches the remote and merges it. Snippets changed on both sides are
+// resolved in favour of the newer version and returned as conflicts, so the
+// working tree never holds conflict markers.
+func (r *Repo) Pull() ([]Conflict, error) {
+	if _, err := r.git("fetch", "--quiet", "origin"); err != nil {
+		return nil, err
+	}
+
+	upstream := "refs/remotes/origin/" + Branch
+	if _, err := r.git("rev-parse", "--verify", "--quiet", upstream); err != nil {
+		// Nothing has been pushed yet
+		return nil, nil
+	}
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// First sync of an empty library: start from the remote
+		_, err := r.git("reset", "--quiet", "--hard", upstream)
+		return nil, err
+	}
+
+	_, mergeErr := r.git(append(append([]string(nil), r.identity...), "merge", "--quiet", "--no-edit", "--allow-unrelated-histories", upstream)...)
+	if mergeErr == nil {
+		return nil, nil
+	}
+
+	unmerged, err := r.git("diff", "--name-only", "--diff-filter=U")
+	if err != nil || unmerged == "" {
+		r.git("merge", "--abort")
+		return nil, mergeErr
+	}
+
+	var conflicts []Conflict
+	for _, path := range strings.Split(unmerged, "\n") {
+		conflict, err := r.resolve(path)
+		if err != nil {
+			r.git("merge", "--abort")
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+
+	if err := r.commit("--no-edit"); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// resolve settles a conflicted file by keeping the newer of the two
+// versions. A version deleted on one side and changed on the other is kept,
+// since losing an edit is worse than bringing back a deleted snippet.
+func (r *Repo) resolve(path string) (*Conflict, error) {
+	type version struct {
+		data    []byte
+		snippet *models.Snippet
+	}
+	// Stage 2 is our side of the merge and stage 3 theirs. A side that
+	// deleted the file, or left it unreadable, has no version.
+	read := func(stage string) *version {
+		data, err := r.blob(stage + path)
+		if err != nil {
+			return nil
+		}
+		s, err := storage.DecodeSnippet(data)
+		if err != nil {
+			return nil
+		}
+		return &version{data: data, snippet: s}
+	}
+	ours, theirs := read(":2:"), read(":3:")
+
+	var kept, lost *version
+	switch {
+	case ours == nil && theirs == nil:
+		return nil, fmt.Errorf("neither version is a valid snippet file")
+	case theirs == nil:
+		kept = ours
+	case ours == nil:
+		kept = theirs
+	case theirs.snippet.UpdatedAt.After(ours.snippet.UpdatedAt):
+		kept, lost = theirs, ours
+	default:
+		kept, lost = ours, theirs
+	}
+
+	if err := os.WriteFile(filepath.Join(r.dir, path), kept.data, 0644); err != nil {
+		return nil, err
+	}
+	if _, err := r.git("add", "--", path); err != nil {
+		return nil, err
+	}
+
+	// Versions that differ only in pin, collection or slug aren't worth a
+	// revision or a warning; this machine's choices win ties
+	if lost == nil || !changed(*kept.snippet, *lost.snippet) {
+		return nil, nil
+	}
+	return &Conflict{Kept: *kept.snippet, Lost: *lost.snippet}, nil
+}
+
+// blob returns the exact content of an object such as ":2:path"
+func (r *Repo) blob(object string) ([]byte, error) {
+	cmd := exec.Command("git", "-C", r.dir, "cat-file", "blob", object)
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file %s: %w", object, err)
+	}
+	return data, nil
+}
+
+// Snippets reads every snippet in the working tree. Should a merge leave two
+// files for one snippet, the newer is returned and the other reported as a
+// conflict.
+func (r *Repo) Snippets() ([]models.Snippet, []Conflict, error) {
+	dir := filepath.Join(r.dir, snippetsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var snippets []models.Snippet
+	var conflicts []Conflict
+	index := make(map[string]int)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		s, err := storage.DecodeSnippet(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", filepath.Join(snippetsDir, entry.Name()), err)
+		}
+
+		i, dup := index[s.UUID]
+		if !dup {
+			index[s.UUID] = len(snippets)
+			snippets = append(snippets, *s)
+			continue
+		}
+		kept, lost := *s, snippets[i]
+		if !kept.UpdatedAt.After(lost.UpdatedAt) {
+			kept, lost = lost, kept
+		}
+		snippets[i] = kept
+		conflicts = append(conflicts, Conflict{Kept: kept, Lost: lost})
+	}
+	return snippets, conflicts, nil
+}
+
+// Push sends the current branch to the remote
+func (r *Repo) Push() error {
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Nothing committed yet, so nothing to share
+		return nil
+	}
+	if _, err := r.git("push", "--quiet", "origin", "HEAD:refs/heads/"+Branch); err != nil {
+		return fmt.Errorf("%w. Another machine may have synced at the same time; run snip sync again", err)
+	}
+	return nil
+}
+
+// Head returns the commit the repository is at, or "" before the first commit
+func (r *Repo) Head() string {
+	head, _ := r.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return head
+}
+
+// Reset moves the repository back to an earlier commit, discarding a merge
+// that couldn't be loaded into the library
+func (r *Repo) Reset(commit string) error {
+	if commit == "" {
+		return nil
+	}
+	_, err := r.git("reset", "--quiet", "--hard", commit)
+	return err
+}
@@ -0,0 +1,262 @@
+package gitsync
+
+import (
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/storage"
+)
+
+// machine is one library and its sync repository
+type machine struct {
+	store storage.Store
+	repo  *Repo
+}
+
+// newMachines returns two machines sharing a fresh bare remote
+func newMachines(t *testing.T) (*machine, *machine) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(ErrNoGit)
+	}
+	// Keep the user's git config, and its identity, out of the test
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v\n%s", err, out)
+	}
+
+	newMachine := func() *machine {
+		store, err := storage.OpenMemory()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		repo, err := Open(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.SetRemote(remote); err != nil {
+			t.Fatal(err)
+		}
+		return &machine{store: store, repo: repo}
+	}
+	return newMachine(), newMachine()
+}
+
+// sync runs one 'snip sync' on m, returning what it changed and the
+// conflicts it resolved
+func (m *machine) sync(t *testing.T) (*Changes, []Conflict) {
+	t.Helper()
+
+	snippets, err := m.store.List(storage.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.repo.Export(snippets); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.repo.Commit("sync"); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := m.repo.Pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, duplicates, err := m.repo.Snippets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts = append(conflicts, duplicates...)
+
+	var changes *Changes
+	err = m.store.(storage.Transactor).Transaction(func(tx storage.Store) error {
+		changes, err = Apply(tx, merged, conflicts)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.repo.Push(); err != nil {
+		t.Fatal(err)
+	}
+	return changes, conflicts
+}
+
+// save adds a snippet to m's library and returns it
+func (m *machine) save(t *testing.T, title, content string) *models.Snippet {
+	t.Helper()
+	id, err := m.store.Save(models.Snippet{Title: title, Content: content, Tags: []string{"sync"}, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.get(t, "", int(id))
+}
+
+// get returns the live snippet with the given UUID, or ID when uuid is ""
+func (m *machine) get(t *testing.T, uuid string, id int) *models.Snippet {
+	t.Helper()
+	var s *models.Snippet
+	var err error
+	if uuid != "" {
+		s, err = m.store.Lookup(uuid)
+	} else {
+		s, err = m.store.Get(id)
+	}
+	if err != nil {
+		t.Fatalf("get %s%d: %v", uuid, id, err)
+	}
+	return s
+}
+
+// edit changes the content of the snippet with the given UUID
+func (m *machine) edit(t *testing.T, uuid, content string) {
+	t.Helper()
+	s := m.get(t, uuid, 0)
+	s.Content = content
+	if err := m.store.Update(*s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// titles returns the titles in m's library, sorted
+func (m *machine) titles(t *testing.T) []string {
+	t.Helper()
+	snippets, err := m.store.List(storage.ListOptions{Sort: storage.SortTitle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, s := range snippets {
+		titles = append(titles, s.Title)
+	}
+	return titles
+}
+
+func TestSyncTwoWay(t *testing.T) {
+	a, b := newMachines(t)
+	prune := a.save(t, "Docker prune", "docker system prune -f")
+	hello := b.save(t, "Go hello", `fmt.Println("hello")`)
+
+	if changes, _ := a.sync(t); *changes != (Changes{}) {
+		t.Errorf("first sync changed %+v", *changes)
+	}
+	if changes, _ := b.sync(t); *changes != (Changes{Added: 1}) {
+		t.Errorf("second machine's sync changed %+v, want one added", *changes)
+	}
+	if changes, _ := a.sync(t); *changes != (Changes{Added: 1}) {
+		t.Errorf("first machine's second sync changed %+v, want one added", *changes)
+	}
+
+	want := []string{"Docker prune", "Go hello"}
+	for name, m := range map[string]*machine{"a": a, "b": b} {
+		if got := m.titles(t); !slices.Equal(got, want) {
+			t.Errorf("%s has %q, want %q", name, got, want)
+		}
+		for _, s := range []*models.Snippet{prune, hello} {
+			got := m.get(t, s.UUID, 0)
+			if got.Content != s.Content || !slices.Equal(got.Tags, s.Tags) || got.Slug != s.Slug {
+				t.Errorf("%s has %+v, want %+v", name, got, s)
+			}
+		}
+	}
+}
+
+func TestSyncConflictKeepsNewer(t *testing.T) {
+	a, b := newMachines(t)
+	s := a.save(t, "Docker prune", "docker system prune -f")
+	a.sync(t)
+	b.sync(t)
+
+	// Timestamps are stored to the second, so leave one between the edits
+	b.edit(t, s.UUID, "docker system prune")
+	time.Sleep(1100 * time.Millisecond)
+	a.edit(t, s.UUID, "docker system prune -af")
+	a.sync(t)
+
+	changes, conflicts := b.sync(t)
+	if len(conflicts) != 1 || conflicts[0].Kept.Content != "docker system prune -af" || conflicts[0].Lost.Content != "docker system prune" {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+	if *changes != (Changes{Updated: 1}) {
+		t.Errorf("changes = %+v, want one updated", *changes)
+	}
+
+	got := b.get(t, s.UUID, 0)
+	if got.Content != "docker system prune -af" {
+		t.Errorf("kept %q, want the newer edit", got.Content)
+	}
+	if conflicts[0].SnippetID != got.ID {
+		t.Errorf("conflict names snippet %d, want %d", conflicts[0].SnippetID, got.ID)
+	}
+	history, err := b.store.History(got.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) < 2 || history[len(history)-2].Content != "docker system prune" {
+		t.Errorf("the older edit is not the previous revision: %+v", history)
+	}
+
+	a.sync(t)
+	if got := a.get(t, s.UUID, 0); got.Content != "docker system prune -af" {
+		t.Errorf("first machine has %q after syncing again", got.Content)
+	}
+}
+
+func TestSyncRemoteDeletion(t *testing.T) {
+	a, b := newMachines(t)
+	s := a.save(t, "Docker prune", "docker system prune -f")
+	a.save(t, "Go hello", `fmt.Println("hello")`)
+	a.sync(t)
+	b.sync(t)
+
+	if err := a.store.Delete(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	a.sync(t)
+
+	changes, _ := b.sync(t)
+	if *changes != (Changes{Deleted: 1}) {
+		t.Errorf("changes = %+v, want one deleted", *changes)
+	}
+	if got := b.titles(t); !slices.Equal(got, []string{"Go hello"}) {
+		t.Errorf("library = %q", got)
+	}
+	trash, err := b.store.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].UUID != s.UUID {
+		t.Errorf("trash = %+v, want the deleted snippet", trash)
+	}
+}
+
+func TestSyncWithoutChanges(t *testing.T) {
+	a, b := newMachines(t)
+	a.save(t, "Docker prune", "docker system prune -f")
+	b.save(t, "Go hello", `fmt.Println("hello")`)
+	a.sync(t)
+	b.sync(t)
+	a.sync(t)
+
+	for name, m := range map[string]*machine{"a": a, "b": b} {
+		head := m.repo.Head()
+		changes, conflicts := m.sync(t)
+		if *changes != (Changes{}) || len(conflicts) != 0 {
+			t.Errorf("%s: sync without changes changed %+v with conflicts %+v", name, *changes, conflicts)
+		}
+		if m.repo.Head() != head {
+			t.Errorf("%s: sync without changes committed", name)
+		}
+	}
+	if a.repo.Head() != b.repo.Head() {
+		t.Errorf("machines ended at %s and %s", a.repo.Head(), b.repo.Head())
+	}
+}
@@ -3,9 +3,77 @@ package models
 import "time"
 
 type Snippet struct {
-    ID        int
-    Title     string
-    Tags      []string
-    CreatedAt time.Time
-    Content   string
+	ID int
+	// UUID identifies the snippet on every machine, unlike ID which is local
+	// to one database
+	UUID string
+	// Slug is a unique, readable name derived from the title when the
+	// snippet is first saved
+	Slug      string
+	Title     string
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// LastUsedAt is zero until the snippet is first printed, copied or edited
+	LastUsedAt time.Time
+	UseCount   int
+	DeletedAt  time.Time `json:"-"` // zero unless the snippet is in the trash
+	// ContentHash identifies the normalized content, for duplicate detection
+	ContentHash string `json:"-"`
+	// Collection is the slash-separated path of the collection the snippet
+	// is filed in, such as "infra/k8s"; it is empty for unfiled snippets
+	Collection string `json:",omitempty"`
+	// Pinned snippets are listed before all others
+	Pinned bool `json:",omitempty"`
+	// Meta holds custom key/value fields such as "owner" or "ticket"
+	Meta map[string]string `json:",omitempty"`
+	// Library names the library the snippet was read from when results
+	// span several libraries; it is empty otherwise
+	Library string `json:"-"`
+	// Encrypted snippets hold ciphertext from package secret in Content
+	// and in every file
+	Encrypted bool
+	// Language names the snippet's programming language, such as "python"
+	Language string `json:",omitempty"`
+	// Description says what the snippet is for, in a sentence or two
+	Description string `json:",omitempty"`
+	// SourceURL is where the snippet was found, if anywhere
+	SourceURL string `json:",omitempty"`
+	Content   string
+	// Files holds the named files of a multi-file snippet, in order. Content
+	// is empty for such snippets.
+	Files []File `json:",omitempty"`
+	// Links point to related snippets; Backlinks are the links other
+	// snippets have to this one
+	Links     []Link `json:",omitempty"`
+	Backlinks []Link `json:"-"`
+}
+
+// Link relates a snippet to another one
+type Link struct {
+	// Rel says how they relate, such as "see-also" or "depends-on"
+	Rel string
+	// UUID identifies the other snippet on every machine
+	UUID string
+	// ID and Title describe the other snippet in this library
+	ID    int    `json:"-"`
+	Title string `json:",omitempty"`
+}
+
+// File is one named file of a multi-file snippet
+type File struct {
+	Name    string
+	Content string
+}
+
+// Revision is a saved version of a snippet. Revision 1 is the snippet as it
+// was first saved; every update adds the next number.
+type Revision struct {
+	SnippetID int
+	Number    int
+	Title     string
+	Tags      []string
+	CreatedAt time.Time
+	Content   string
+	Files     []File
 }
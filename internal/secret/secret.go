@@ -0,0 +1,111 @@
+// Package secret encrypts snippet content with a key derived from a
+// passphrase, using scrypt and AES-256-GCM.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// prefix marks encrypted content and names the format version, so the
+// parameters below can change without breaking existing snippets
+const prefix = "snip-encrypted:v1:"
+
+// scrypt parameters recommended for interactive use
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keySize = 32
+	saltLen = 16
+)
+
+// ErrWrongPassphrase is returned when content can't be decrypted with the
+// given passphrase, or has been tampered with
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted content")
+
+// IsEncrypted reports whether content was produced by Encrypt
+func IsEncrypted(content string) bool {
+	return strings.HasPrefix(content, prefix)
+}
+
+// Encrypt seals plaintext with a key derived from passphrase. The result is
+// printable text that carries its own salt and nonce.
+func Encrypt(plaintext string, passphrase string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// salt | nonce | ciphertext, with the prefix authenticated as well
+	sealed := append(salt, nonce...)
+	sealed = gcm.Seal(sealed, nonce, []byte(plaintext), []byte(prefix))
+
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens content produced by Encrypt
+func Decrypt(content string, passphrase string) (string, error) {
+	if !IsEncrypted(content) {
+		return "", errors.New("content is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(content, prefix)))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted content: %w", err)
+	}
+	if len(sealed) < saltLen {
+		return "", errors.New("invalid encrypted content: too short")
+	}
+
+	salt := sealed[:saltLen]
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	rest := sealed[saltLen:]
+	if len(rest) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted content: too short")
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(prefix))
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+
+	return string(plaintext), nil
+}
+
+// newGCM derives the key for a passphrase and salt and returns its AEAD
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
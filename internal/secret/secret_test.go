@@ -0,0 +1,107 @@
+package secret
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, plaintext := range []string{"", "export TOKEN=abc123", "multi\nline\n\tcontent ✓"} {
+		sealed, err := Encrypt(plaintext, "correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(sealed) {
+			t.Errorf("Encrypt(%q) = %q, missing the prefix", plaintext, sealed)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Errorf("Encrypt(%q) = %q, contains the plaintext", plaintext, sealed)
+		}
+
+		opened, err := Decrypt(sealed, "correct horse")
+		if err != nil {
+			t.Fatalf("Decrypt(Encrypt(%q)): %v", plaintext, err)
+		}
+		if opened != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, opened)
+		}
+	}
+}
+
+func TestEncryptSalts(t *testing.T) {
+	first, err := Encrypt("same", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Encrypt("same", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("encrypting twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptWrongPassphrase(t *testing.T) {
+	sealed, err := Encrypt("export TOKEN=abc123", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, passphrase := range []string{"wrong horse", "", "correct horse "} {
+		opened, err := Decrypt(sealed, passphrase)
+		if !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Decrypt with %q: %v, want ErrWrongPassphrase", passphrase, err)
+		}
+		if opened != "" {
+			t.Errorf("Decrypt with %q returned %q", passphrase, opened)
+		}
+	}
+}
+
+func TestDecryptDamaged(t *testing.T) {
+	sealed, err := Encrypt("export TOKEN=abc123", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reseal encodes raw as Encrypt would after fn has changed it
+	reseal := func(fn func([]byte) []byte) string {
+		return prefix + base64.StdEncoding.EncodeToString(fn(append([]byte(nil), raw...)))
+	}
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"no prefix", strings.TrimPrefix(sealed, prefix)},
+		{"plain text", "export TOKEN=abc123"},
+		{"older version", "snip-encrypted:v0:" + strings.TrimPrefix(sealed, prefix)},
+		{"prefix only", prefix},
+		{"not base64", prefix + "!!!not base64!!!"},
+		{"cut mid-base64", sealed[:len(sealed)-3]},
+		{"shorter than the salt", reseal(func(b []byte) []byte { return b[:saltLen-1] })},
+		{"shorter than the nonce", reseal(func(b []byte) []byte { return b[:saltLen+4] })},
+		{"missing the tag", reseal(func(b []byte) []byte { return b[:len(b)-1] })},
+		{"flipped salt", reseal(func(b []byte) []byte { b[0] ^= 1; return b })},
+		{"flipped nonce", reseal(func(b []byte) []byte { b[saltLen] ^= 1; return b })},
+		{"flipped ciphertext", reseal(func(b []byte) []byte { b[len(b)-20] ^= 1; return b })},
+		{"extra byte", reseal(func(b []byte) []byte { return append(b, 0) })},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opened, err := Decrypt(c.content, "pass")
+			if err == nil {
+				t.Fatalf("decrypted %q", opened)
+			}
+			if opened != "" {
+				t.Errorf("returned %q along with %v", opened, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CleanCollection normalizes a collection path such as "infra/k8s/debug":
+// surrounding slashes and spaces are dropped, and "" means unfiled. Segments
+// may not be empty, "." or "..", or start with a dot, so a collection is
+// always safe to use as a directory name.
+func CleanCollection(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		switch {
+		case segment == "":
+			return "", fmt.Errorf("invalid collection '%s': empty name between slashes", path)
+		case strings.HasPrefix(segment, "."):
+			return "", fmt.Errorf("invalid collection '%s': names cannot start with '.'", path)
+		case strings.ContainsAny(segment, `\:*?"<>|`):
+			return "", fmt.Errorf("invalid collection '%s': names cannot contain \\ : * ? \" < > |", path)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// InCollection reports whether a snippet filed in collection is listed by
+// opts: everything when opts names no collection, otherwise that collection
+// and, if Recursive, the collections nested in it
+func (opts ListOptions) InCollection(collection string) bool {
+	if opts.Collection == "" {
+		return true
+	}
+	return collection == opts.Collection ||
+		(opts.Recursive && strings.HasPrefix(collection, opts.Collection+"/"))
+}
+
+// collectionCondition returns the WHERE condition and parameters that
+// InCollection applies in SQL, or "" for every collection
+func (opts ListOptions) collectionCondition() (string, []any) {
+	if opts.Collection == "" {
+		return "", nil
+	}
+	if opts.Recursive {
+		// substr rather than LIKE, so _ and % in names match literally
+		prefix := opts.Collection + "/"
+		return "(s.collection = ? OR substr(s.collection, 1, ?) = ?)",
+			[]any{opts.Collection, utf8.RuneCountInString(prefix), prefix}
+	}
+	return "s.collection = ?", []any{opts.Collection}
+}
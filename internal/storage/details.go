@@ -0,0 +1,61 @@
+package storage
+
+import "database/sql"
+
+// ftsDetailsOf renders the description, language and source URL columns of
+// the snippets row aliased as row, in snippets_fts column order
+func ftsDetailsOf(row string) string {
+	return `COALESCE(` + row + `.description, ''), COALESCE(` + row + `.language, ''), COALESCE(` + row + `.source_url, '')`
+}
+
+// ftsReindex indexes every snippet for Repair. snippets_fts must be empty.
+func ftsReindex() string {
+	return `INSERT INTO snippets_fts (rowid, title, tags, content, description, language, source_url)
+		SELECT s.id, COALESCE(s.title, ''), ` + ftsTagsFor("s.id") + `, ` + ftsContentOf("s.id") + `, ` + ftsDetailsOf("s") + `
+		FROM snippets s`
+}
+
+// addDetails adds the language, description and source_url columns and
+// rebuilds the search index with a column for each, so they can be searched
+func addDetails(tx *sql.Tx) error {
+	return execStatements(
+		`ALTER TABLE snippets ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE snippets ADD COLUMN description TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE snippets ADD COLUMN source_url TEXT NOT NULL DEFAULT ''`,
+		`DROP TRIGGER snippets_fts_insert`,
+		`DROP TRIGGER snippets_fts_update`,
+		`DROP TABLE snippets_fts`,
+		`CREATE VIRTUAL TABLE snippets_fts USING fts5(
+			title, tags, content, description, language, source_url,
+			tokenize = 'unicode61 remove_diacritics 2',
+			prefix = '2 3'
+		)`,
+		`CREATE TRIGGER snippets_fts_insert AFTER INSERT ON snippets BEGIN
+			INSERT INTO snippets_fts (rowid, title, tags, content, description, language, source_url)
+			VALUES (new.id, COALESCE(new.title, ''), '', COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.id), ''),
+				COALESCE(new.description, ''), COALESCE(new.language, ''), COALESCE(new.source_url, ''));
+		END`,
+		`CREATE TRIGGER snippets_fts_update
+			AFTER UPDATE OF title, content, encrypted, description, language, source_url ON snippets BEGIN
+			UPDATE snippets_fts SET title = COALESCE(new.title, ''), content = COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.id), ''),
+				description = COALESCE(new.description, ''), language = COALESCE(new.language, ''),
+				source_url = COALESCE(new.source_url, '')
+			WHERE rowid = new.id;
+		END`,
+		`INSERT INTO snippets_fts (rowid, title, tags, content, description, language, source_url)
+			SELECT s.id, COALESCE(s.title, ''), COALESCE((SELECT group_concat(t.name, ' ')
+				FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = s.id), ''), COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = s.id), ''),
+				COALESCE(s.description, ''), COALESCE(s.language, ''), COALESCE(s.source_url, '')
+			FROM snippets s`,
+	)(tx)
+}
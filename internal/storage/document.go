@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// snippetDocument is a whole snippet in one file: the front matter of the
+// files backend plus its slug, followed by its content. The files of a
+// multi-file snippet are kept in the header instead.
+type snippetDocument struct {
+	frontMatter `yaml:",inline"`
+	Slug        string         `yaml:"slug"`
+	Contents    []documentFile `yaml:"contents,omitempty"`
+}
+
+// documentFile is one file of a multi-file snippet in a snippetDocument
+type documentFile struct {
+	Name    string `yaml:"name"`
+	Content string `yaml:"content"`
+}
+
+// EncodeSnippet renders a snippet, minus local state such as its ID, usage
+// and trash status, as a single text file. ext is the file extension that
+// suits its content.
+func EncodeSnippet(s models.Snippet) (ext string, data []byte, err error) {
+	doc := snippetDocument{
+		frontMatter: frontMatter{
+			Title:       s.Title,
+			Tags:        CleanTags(s.Tags),
+			Created:     formatTimestamp(s.CreatedAt),
+			Updated:     formatTimestamp(s.UpdatedAt),
+			UUID:        s.UUID,
+			Encrypted:   s.Encrypted,
+			Language:    s.Language,
+			Description: s.Description,
+			SourceURL:   s.SourceURL,
+			Pinned:      s.Pinned,
+			Meta:        s.Meta,
+			Collection:  s.Collection,
+			Links:       frontMatterLinks(s.Links),
+		},
+		Slug: s.Slug,
+	}
+	if doc.Tags == nil {
+		doc.Tags = []string{}
+	}
+	for _, file := range s.Files {
+		doc.Contents = append(doc.Contents, documentFile{Name: file.Name, Content: file.Content})
+	}
+
+	header, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ext = extensionFor(s)
+	if len(s.Files) > 0 {
+		ext = ".yaml"
+	}
+	data = []byte(frontMatterDelimiter + string(header) + frontMatterDelimiter + s.Content)
+	return ext, data, nil
+}
+
+// DecodeSnippet parses a file written by EncodeSnippet
+func DecodeSnippet(data []byte) (*models.Snippet, error) {
+	header, content, ok := splitHeader(data)
+	if !ok {
+		return nil, fmt.Errorf("missing snippet header")
+	}
+
+	var doc snippetDocument
+	if err := yaml.Unmarshal([]byte(header), &doc); err != nil {
+		return nil, fmt.Errorf("invalid snippet header: %w", err)
+	}
+	if doc.UUID == "" {
+		return nil, fmt.Errorf("snippet header has no uuid")
+	}
+
+	s := &models.Snippet{
+		UUID:        doc.UUID,
+		Slug:        doc.Slug,
+		Title:       doc.Title,
+		Tags:        CleanTags(doc.Tags),
+		Encrypted:   doc.Encrypted,
+		Language:    doc.Language,
+		Description: doc.Description,
+		SourceURL:   doc.SourceURL,
+		Pinned:      doc.Pinned,
+		Meta:        doc.Meta,
+		Collection:  doc.Collection,
+		Links:       snippetLinks(doc.Links),
+		Content:     content,
+	}
+	for _, file := range doc.Contents {
+		s.Files = append(s.Files, models.File{Name: file.Name, Content: file.Content})
+	}
+	if len(s.Files) > 0 {
+		s.Content = ""
+	}
+
+	var err error
+	if s.CreatedAt, err = parseTimestamp(doc.Created); err != nil {
+		return nil, fmt.Errorf("invalid created: %w", err)
+	}
+	s.UpdatedAt = s.CreatedAt
+	if doc.Updated != "" {
+		if s.UpdatedAt, err = parseTimestamp(doc.Updated); err != nil {
+			return nil, fmt.Errorf("invalid updated: %w", err)
+		}
+	}
+	s.ContentHash = snippetHash(*s)
+	return s, nil
+}
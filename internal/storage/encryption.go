@@ -0,0 +1,23 @@
+package storage
+
+import "database/sql"
+
+// addEncrypted adds the encrypted flag and rebuilds the full-text triggers
+// so the content of encrypted snippets, which is ciphertext, never reaches
+// the search index. Titles and tags stay searchable.
+func addEncrypted(tx *sql.Tx) error {
+	return execStatements(
+		`ALTER TABLE snippets ADD COLUMN encrypted INTEGER NOT NULL DEFAULT 0`,
+		`DROP TRIGGER snippets_fts_insert`,
+		`DROP TRIGGER snippets_fts_update`,
+		`CREATE TRIGGER snippets_fts_insert AFTER INSERT ON snippets BEGIN
+			INSERT INTO snippets_fts (rowid, title, tags, content)
+			VALUES (new.id, COALESCE(new.title, ''), '', CASE WHEN new.encrypted THEN '' ELSE COALESCE(new.content, '') END);
+		END`,
+		`CREATE TRIGGER snippets_fts_update AFTER UPDATE OF title, content, encrypted ON snippets BEGIN
+			UPDATE snippets_fts SET title = COALESCE(new.title, ''),
+				content = CASE WHEN new.encrypted THEN '' ELSE COALESCE(new.content, '') END
+			WHERE rowid = new.id;
+		END`,
+	)(tx)
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// filesColumn selects a snippet's files, in their saved order, as a JSON
+// array of {"Name", "Content"} objects. It expects the snippets table to be
+// aliased as s.
+const filesColumn = `(SELECT json_group_array(json_object('Name', f.name, 'Content', f.content) ORDER BY f.position)
+	FROM snippet_files f WHERE f.snippet_id = s.id)`
+
+// ftsContentOf renders the indexable content of the snippet identified by
+// idExpr: its content followed by the name and content of every file.
+// Encrypted snippets contribute nothing. Migrations keep their own copy of
+// this SQL, as released.
+func ftsContentOf(idExpr string) string {
+	return `COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+		COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+			FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+		END FROM snippets sn WHERE sn.id = ` + idExpr + `), '')`
+}
+
+// createFiles adds the snippet_files table, keeps file contents in the
+// search index and records files with every revision
+func createFiles(tx *sql.Tx) error {
+	return execStatements(
+		`CREATE TABLE snippet_files (
+			snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL DEFAULT 0,
+			name TEXT NOT NULL,
+			content TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (snippet_id, name)
+		)`,
+		`ALTER TABLE snippet_revisions ADD COLUMN files TEXT NOT NULL DEFAULT '[]'`,
+		`DROP TRIGGER snippets_fts_insert`,
+		`DROP TRIGGER snippets_fts_update`,
+		`CREATE TRIGGER snippets_fts_insert AFTER INSERT ON snippets BEGIN
+			INSERT INTO snippets_fts (rowid, title, tags, content)
+			VALUES (new.id, COALESCE(new.title, ''), '', COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.id), ''));
+		END`,
+		`CREATE TRIGGER snippets_fts_update AFTER UPDATE OF title, content, encrypted ON snippets BEGIN
+			UPDATE snippets_fts SET title = COALESCE(new.title, ''), content = COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.id), '')
+			WHERE rowid = new.id;
+		END`,
+		`CREATE TRIGGER snippets_fts_file_insert AFTER INSERT ON snippet_files BEGIN
+			UPDATE snippets_fts SET content = COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.snippet_id), '')
+			WHERE rowid = new.snippet_id;
+		END`,
+		`CREATE TRIGGER snippets_fts_file_update AFTER UPDATE ON snippet_files BEGIN
+			UPDATE snippets_fts SET content = COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = new.snippet_id), '')
+			WHERE rowid = new.snippet_id;
+		END`,
+		`CREATE TRIGGER snippets_fts_file_delete AFTER DELETE ON snippet_files BEGIN
+			UPDATE snippets_fts SET content = COALESCE((SELECT CASE WHEN sn.encrypted THEN '' ELSE
+				COALESCE(sn.content, '') || ' ' || COALESCE((SELECT group_concat(f.name || ' ' || f.content, ' ')
+					FROM snippet_files f WHERE f.snippet_id = sn.id), '')
+				END FROM snippets sn WHERE sn.id = old.snippet_id), '')
+			WHERE rowid = old.snippet_id;
+		END`,
+	)(tx)
+}
+
+// ValidateFiles checks that every file of a multi-file snippet has a plain,
+// unique name, so it can be written out safely by checkout
+func ValidateFiles(files []models.File) error {
+	seen := make(map[string]bool)
+	for _, file := range files {
+		name := file.Name
+		switch {
+		case strings.TrimSpace(name) == "":
+			return fmt.Errorf("file names cannot be empty")
+		case name == "." || name == ".." || strings.ContainsAny(name, `/\`):
+			return fmt.Errorf("invalid file name '%s'. Names cannot contain directories", name)
+		case seen[name]:
+			return fmt.Errorf("duplicate file name '%s'", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// JoinFiles renders the files of a multi-file snippet as one text, each file
+// introduced by a "==> name <==" header
+func JoinFiles(files []models.File) string {
+	var text strings.Builder
+	for i, file := range files {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString("==> " + file.Name + " <==\n")
+		text.WriteString(file.Content)
+		if !strings.HasSuffix(file.Content, "\n") {
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
+
+// snippetHash returns the content hash of a snippet, covering every file of
+// a multi-file snippet
+func snippetHash(s models.Snippet) string {
+	if len(s.Files) > 0 {
+		return ContentHash(JoinFiles(s.Files))
+	}
+	return ContentHash(s.Content)
+}
+
+// decodeFiles parses the JSON array produced by filesColumn or stored with
+// a revision
+func decodeFiles(filesJSON string) ([]models.File, error) {
+	var files []models.File
+	if filesJSON == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(filesJSON), &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return files, nil
+}
+
+// encodeFiles renders files for the revisions table
+func encodeFiles(files []models.File) (string, error) {
+	if files == nil {
+		files = []models.File{}
+	}
+	data, err := json.Marshal(files)
+	return string(data), err
+}
+
+// setFiles replaces the files attached to a snippet
+func setFiles(tx *sql.Tx, snippetID int64, files []models.File) error {
+	if err := ValidateFiles(files); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM snippet_files WHERE snippet_id = ?`, snippetID); err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		_, err := tx.Exec(`INSERT INTO snippet_files (snippet_id, position, name, content) VALUES (?, ?, ?, ?)`,
+			snippetID, i, file.Name, file.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
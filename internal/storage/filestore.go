@@ -0,0 +1,1366 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// fileStoreMetaDir holds what doesn't belong in the snippet files
+// themselves: local IDs and usage counts, revision history and the trash
+const fileStoreMetaDir = ".snip"
+
+// FileStore is the Store implementation that keeps each snippet as a plain
+// file, <slug>.<ext> with a YAML front matter header, so a library can be
+// grepped, edited and put under version control directly. A multi-file
+// snippet is a <slug> directory holding its files and a .snippet.yaml; any
+// other directory is a collection.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// fileState is the per-machine state of a file library, kept out of
+// version control: numeric IDs and usage, both keyed by UUID
+type fileState struct {
+	NextID int                  `json:"next_id"`
+	IDs    map[string]int       `json:"ids"`
+	Usage  map[string]fileUsage `json:"usage,omitempty"`
+}
+
+// fileUsage records how often a snippet was used
+type fileUsage struct {
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	UseCount   int    `json:"use_count"`
+}
+
+// fileRevision is a revision as stored in a snippet's history file
+type fileRevision struct {
+	Number    int           `json:"number"`
+	Title     string        `json:"title"`
+	Tags      []string      `json:"tags"`
+	Content   string        `json:"content"`
+	Files     []models.File `json:"files,omitempty"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// fileEntry is a snippet read from disk, along with where it lives
+type fileEntry struct {
+	snippet models.Snippet
+	// path is the snippet file, or the directory of a multi-file snippet
+	path string
+	// links are the links kept in the front matter, including those to
+	// snippets in the trash, which snippet.Links leaves out
+	links []models.Link
+}
+
+// bundle reports whether the entry is a multi-file snippet directory
+func (e *fileEntry) bundle() bool {
+	return len(e.snippet.Files) > 0
+}
+
+// fileLibrary is everything read from a file library's directory
+type fileLibrary struct {
+	state *fileState
+	live  []*fileEntry
+	trash []*fileEntry
+}
+
+// OpenFiles opens the file library in dir, creating the directory if needed
+func OpenFiles(dir string) (*FileStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range []string{dir, filepath.Join(dir, fileStoreMetaDir, "history"), filepath.Join(dir, fileStoreMetaDir, "trash")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create library directory: %w", err)
+		}
+	}
+
+	// IDs and usage are local to each machine
+	ignore := filepath.Join(dir, fileStoreMetaDir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("state.json\n"), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Dir returns the directory holding the library
+func (store *FileStore) Dir() string {
+	return store.dir
+}
+
+// Close releases nothing; every operation reads and writes files directly
+func (store *FileStore) Close() error {
+	return nil
+}
+
+func (store *FileStore) statePath() string {
+	return filepath.Join(store.dir, fileStoreMetaDir, "state.json")
+}
+
+func (store *FileStore) trashDir() string {
+	return filepath.Join(store.dir, fileStoreMetaDir, "trash")
+}
+
+func (store *FileStore) historyPath(uuid string) string {
+	return filepath.Join(store.dir, fileStoreMetaDir, "history", uuid+".json")
+}
+
+// load reads every snippet in the library. Files added by hand are adopted:
+// they get a UUID, and front matter if they had none, written back to disk.
+func (store *FileStore) load() (*fileLibrary, error) {
+	state := &fileState{NextID: 1, IDs: map[string]int{}, Usage: map[string]fileUsage{}}
+	data, err := os.ReadFile(store.statePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", store.statePath(), err)
+		}
+		if state.IDs == nil {
+			state.IDs = map[string]int{}
+		}
+		if state.Usage == nil {
+			state.Usage = map[string]fileUsage{}
+		}
+	}
+
+	lib := &fileLibrary{state: state}
+	if lib.live, err = store.readDir(store.dir, ""); err != nil {
+		return nil, err
+	}
+	if lib.trash, err = store.readDir(store.trashDir(), ""); err != nil {
+		return nil, err
+	}
+
+	// New snippets get the next IDs, oldest first
+	all := append(append([]*fileEntry(nil), lib.live...), lib.trash...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].snippet.CreatedAt.Before(all[j].snippet.CreatedAt)
+	})
+	// Two processes saving at once can hand out the same ID; the newer
+	// snippet then gets a fresh one
+	changed := false
+	taken := map[int]bool{}
+	for _, e := range all {
+		id, ok := state.IDs[e.snippet.UUID]
+		if !ok || taken[id] {
+			id = state.NextID
+			state.NextID++
+			state.IDs[e.snippet.UUID] = id
+			changed = true
+		}
+		taken[id] = true
+		e.snippet.ID = id
+		if usage, ok := state.Usage[e.snippet.UUID]; ok {
+			e.snippet.UseCount = usage.UseCount
+			if usage.LastUsedAt != "" {
+				if e.snippet.LastUsedAt, err = parseTimestamp(usage.LastUsedAt); err != nil {
+					return nil, fmt.Errorf("invalid %s: last used time of %s: %w", store.statePath(), e.snippet.UUID, err)
+				}
+			}
+		}
+	}
+
+	// Ties in every order fall back to ID order, as they do in SQLite
+	for _, entries := range [][]*fileEntry{lib.live, lib.trash} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].snippet.ID < entries[j].snippet.ID })
+	}
+
+	if changed {
+		if err := store.saveState(state); err != nil {
+			return nil, err
+		}
+	}
+	resolveLinks(lib)
+	return lib, nil
+}
+
+// resolveLinks fills in the links and backlinks of every snippet from the
+// links in the front matter, leaving out snippets in the trash
+func resolveLinks(lib *fileLibrary) {
+	live := make(map[string]*fileEntry)
+	for _, e := range lib.live {
+		live[e.snippet.UUID] = e
+	}
+
+	for _, entries := range [][]*fileEntry{lib.live, lib.trash} {
+		for _, e := range entries {
+			for _, link := range e.links {
+				target := live[link.UUID]
+				if target == nil || target == e {
+					continue
+				}
+				e.snippet.Links = append(e.snippet.Links, models.Link{
+					Rel: link.Rel, UUID: link.UUID, ID: target.snippet.ID, Title: target.snippet.Title,
+				})
+				if e.snippet.DeletedAt.IsZero() {
+					target.snippet.Backlinks = append(target.snippet.Backlinks, models.Link{
+						Rel: link.Rel, UUID: e.snippet.UUID, ID: e.snippet.ID, Title: e.snippet.Title,
+					})
+				}
+			}
+		}
+	}
+}
+
+// readDir reads the snippets in dir, which holds the given collection, and
+// in the collections below it. The trash is read as one flat directory;
+// its snippets remember their collection in the front matter.
+func (store *FileStore) readDir(dir string, collection string) ([]*fileEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	trash := dir == store.trashDir()
+	var result []*fileEntry
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if entry.IsDir() && !trash && !isBundle(path) {
+			nested, err := store.readDir(path, joinCollection(collection, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, nested...)
+			continue
+		}
+
+		e, err := store.readEntry(path, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if e == nil {
+			continue
+		}
+		if !trash {
+			e.snippet.Collection = collection
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// isBundle reports whether dir is a multi-file snippet rather than a collection
+func isBundle(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, bundleMetaFile))
+	return err == nil
+}
+
+// joinCollection returns the path of a collection nested in parent
+func joinCollection(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// readEntry reads one snippet file or multi-file snippet directory. It
+// returns nil for directories that aren't snippets.
+func (store *FileStore) readEntry(path string, entry os.DirEntry) (*fileEntry, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	name := entry.Name()
+	e := &fileEntry{path: path}
+	var fm *frontMatter
+
+	if entry.IsDir() {
+		if !isBundle(path) {
+			return nil, nil
+		}
+		if fm, e.snippet.Files, err = readBundle(path); err != nil {
+			return nil, err
+		}
+		e.snippet.Slug = name
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if fm, e.snippet.Content, err = splitFrontMatter(data); err != nil {
+			return nil, err
+		}
+		e.snippet.Slug = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	adopt := fm == nil || fm.UUID == "" || fm.Created == ""
+	if fm == nil {
+		fm = &frontMatter{}
+	}
+	if fm.UUID == "" {
+		fm.UUID = uuid.NewString()
+	}
+	if fm.Created == "" {
+		fm.Created = formatTimestamp(info.ModTime())
+	}
+	if fm.Title == "" {
+		fm.Title = e.snippet.Slug
+	}
+
+	s := &e.snippet
+	s.UUID = strings.ToLower(fm.UUID)
+	s.Title = fm.Title
+	s.Tags = CleanTags(fm.Tags)
+	s.Encrypted = fm.Encrypted
+	s.Language = fm.Language
+	s.Description = fm.Description
+	s.SourceURL = fm.SourceURL
+	s.Pinned = fm.Pinned
+	if s.Meta, err = CleanMeta(fm.Meta); err != nil {
+		return nil, err
+	}
+	s.Collection = fm.Collection
+	e.links = snippetLinks(fm.Links)
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"created", fm.Created, &s.CreatedAt},
+		{"updated", fm.Updated, &s.UpdatedAt},
+		{"deleted", fm.Deleted, &s.DeletedAt},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.dest, err = parseTimestamp(field.value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = s.CreatedAt
+	}
+	s.ContentHash = snippetHash(*s)
+
+	if adopt {
+		if err := store.writeEntry(e); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(store.historyPath(s.UUID)); os.IsNotExist(err) {
+			if err := store.recordRevision(*s, s.UpdatedAt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e, nil
+}
+
+// writeEntry writes a snippet to its path
+func (store *FileStore) writeEntry(e *fileEntry) error {
+	s := e.snippet
+	fm := &frontMatter{
+		Title:       s.Title,
+		Tags:        CleanTags(s.Tags),
+		Created:     formatTimestamp(s.CreatedAt),
+		UUID:        s.UUID,
+		Encrypted:   s.Encrypted,
+		Language:    s.Language,
+		Description: s.Description,
+		SourceURL:   s.SourceURL,
+		Pinned:      s.Pinned,
+		Meta:        s.Meta,
+		Links:       frontMatterLinks(e.links),
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+	if s.UpdatedAt.Sub(s.CreatedAt) >= time.Second {
+		fm.Updated = formatTimestamp(s.UpdatedAt)
+	}
+	if !s.DeletedAt.IsZero() {
+		// Outside the trash, the directory says which collection a snippet is in
+		fm.Deleted = formatTimestamp(s.DeletedAt)
+		fm.Collection = s.Collection
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return err
+	}
+	if e.bundle() {
+		return writeBundle(e.path, fm, s.Files)
+	}
+
+	data, err := renderFrontMatter(fm, s.Content)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(e.path, data)
+}
+
+// moveEntry writes a snippet to a new path and removes it from the old one
+func (store *FileStore) moveEntry(e *fileEntry, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	old := e.path
+	e.path = path
+	if err := store.writeEntry(e); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	store.pruneCollections(filepath.Dir(old))
+	return nil
+}
+
+// pruneCollections removes dir and the collection directories above it while
+// they are empty, so a collection goes away with its last snippet
+func (store *FileStore) pruneCollections(dir string) {
+	if rel, err := filepath.Rel(store.dir, dir); err != nil || strings.HasPrefix(rel, fileStoreMetaDir) {
+		return
+	}
+	for dir != store.dir && strings.HasPrefix(dir, store.dir+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (store *FileStore) saveState(state *fileState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(store.statePath(), append(data, '\n'))
+}
+
+// collectionDir returns the directory of a collection
+func (store *FileStore) collectionDir(collection string) string {
+	return filepath.Join(store.dir, filepath.FromSlash(collection))
+}
+
+// checkCollection refuses collections that would put snippets inside a
+// multi-file snippet's directory
+func (store *FileStore) checkCollection(collection string) error {
+	path := ""
+	for _, segment := range strings.Split(collection, "/") {
+		path = joinCollection(path, segment)
+		if isBundle(store.collectionDir(path)) {
+			return fmt.Errorf("'%s' is a multi-file snippet, not a collection", path)
+		}
+	}
+	return nil
+}
+
+// entryPath returns where a live snippet is stored, given its extension
+func (store *FileStore) entryPath(s models.Snippet, ext string) string {
+	dir := store.collectionDir(s.Collection)
+	if len(s.Files) > 0 {
+		return filepath.Join(dir, s.Slug)
+	}
+	return filepath.Join(dir, s.Slug+ext)
+}
+
+// slugTaken reports whether a slug is used by a snippet, in the library or
+// the trash, or by any other file where the snippet would be written
+func (store *FileStore) slugTaken(lib *fileLibrary, slug string, collection string) bool {
+	for _, e := range append(append([]*fileEntry(nil), lib.live...), lib.trash...) {
+		if e.snippet.Slug == slug {
+			return true
+		}
+	}
+	for _, dir := range []string{store.dir, store.collectionDir(collection), store.trashDir()} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, slug+".*")); len(matches) > 0 {
+			return true
+		}
+		if _, err := os.Stat(filepath.Join(dir, slug)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// recordRevision appends the given state of a snippet to its history
+func (store *FileStore) recordRevision(s models.Snippet, at time.Time) error {
+	revisions, err := store.readHistory(s.UUID)
+	if err != nil {
+		return err
+	}
+
+	revisions = append(revisions, fileRevision{
+		Number:    len(revisions) + 1,
+		Title:     s.Title,
+		Tags:      CleanTags(s.Tags),
+		Content:   s.Content,
+		Files:     s.Files,
+		CreatedAt: formatTimestamp(at),
+	})
+
+	data, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(store.historyPath(s.UUID), append(data, '\n'))
+}
+
+func (store *FileStore) readHistory(uuid string) ([]fileRevision, error) {
+	data, err := os.ReadFile(store.historyPath(uuid))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []fileRevision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("invalid history for snippet %s: %w", uuid, err)
+	}
+	return revisions, nil
+}
+
+// find returns the entry with the given ID
+func find(entries []*fileEntry, id int) *fileEntry {
+	for _, e := range entries {
+		if e.snippet.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// snippetsOf copies the snippets out of entries
+func snippetsOf(entries []*fileEntry) []models.Snippet {
+	snippets := make([]models.Snippet, 0, len(entries))
+	for _, e := range entries {
+		snippets = append(snippets, e.snippet)
+	}
+	return snippets
+}
+
+// Save writes a new snippet file and returns its ID
+func (store *FileStore) Save(s models.Snippet) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := ValidateFiles(s.Files); err != nil {
+		return 0, err
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return 0, err
+	}
+
+	if s.UUID == "" {
+		s.UUID = uuid.NewString()
+	} else {
+		parsed, err := uuid.Parse(s.UUID)
+		if err != nil {
+			return 0, fmt.Errorf("invalid UUID '%s': %w", s.UUID, err)
+		}
+		s.UUID = parsed.String()
+		if _, taken := lib.state.IDs[s.UUID]; taken {
+			return 0, fmt.Errorf("a snippet with UUID %s already exists", s.UUID)
+		}
+	}
+
+	base := Slugify(s.Slug)
+	if s.Slug == "" {
+		base = Slugify(s.Title)
+	}
+	s.Slug = base
+	if s.Collection, err = CleanCollection(s.Collection); err != nil {
+		return 0, err
+	}
+	if s.Collection != "" {
+		if err := store.checkCollection(s.Collection); err != nil {
+			return 0, err
+		}
+	}
+	for n := 2; store.slugTaken(lib, s.Slug, s.Collection); n++ {
+		s.Slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	if s.Meta, err = CleanMeta(s.Meta); err != nil {
+		return 0, err
+	}
+
+	s.CreatedAt = s.CreatedAt.UTC().Truncate(time.Second)
+	s.UpdatedAt = s.CreatedAt
+	s.Tags = CleanTags(s.Tags)
+	if len(s.Files) > 0 {
+		s.Content = ""
+	}
+
+	e := &fileEntry{snippet: s, path: store.entryPath(s, extensionFor(s))}
+	if err := store.writeEntry(e); err != nil {
+		return 0, err
+	}
+	if err := store.recordRevision(s, s.CreatedAt); err != nil {
+		return 0, err
+	}
+
+	id := lib.state.NextID
+	lib.state.NextID++
+	lib.state.IDs[s.UUID] = id
+	return int64(id), store.saveState(lib.state)
+}
+
+// Get returns a single snippet by its ID
+func (store *FileStore) Get(id int) (*models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return nil, fmt.Errorf("snippet with ID %d not found", id)
+	}
+	return &e.snippet, nil
+}
+
+// Lookup returns the snippet outside the trash identified by ref, which may
+// be a numeric ID, a UUID or a slug
+func (store *FileStore) Lookup(ref string) (*models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := strconv.Atoi(ref)
+	for _, e := range lib.live {
+		if e.snippet.ID == id || e.snippet.UUID == strings.ToLower(ref) || e.snippet.Slug == ref {
+			return &e.snippet, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindByTitle returns snippets outside the trash whose title equals title,
+// ignoring case. When there are none, it returns those whose title or slug
+// starts with title instead, oldest first.
+func (store *FileStore) FindByTitle(title string) ([]models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var exact, prefix []models.Snippet
+	lower := strings.ToLower(title)
+	for _, s := range snippetsOf(lib.live) {
+		switch {
+		case strings.EqualFold(s.Title, title):
+			exact = append(exact, s)
+		case strings.HasPrefix(strings.ToLower(s.Title), lower) || strings.HasPrefix(strings.ToLower(s.Slug), lower):
+			prefix = append(prefix, s)
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = prefix
+	}
+	sortOldestFirst(matches)
+	return matches, nil
+}
+
+// List returns every snippet not in the trash in the requested order
+func (store *FileStore) List(opts ListOptions) ([]models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []models.Snippet
+	for _, s := range snippetsOf(lib.live) {
+		if opts.InCollection(s.Collection) && (s.Pinned || !opts.Pinned) && opts.HasMeta(s.Meta) {
+			snippets = append(snippets, s)
+		}
+	}
+	if err := sortSnippets(snippets, opts.Sort); err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// Search returns snippets matching query in title, tags, or content, best
+// match first, with the same query syntax and weighting as the full-text
+// index of the SQLite backend
+func (store *FileStore) Search(query string, tagFilter string) ([]models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	tagFilter = strings.TrimSpace(tagFilter)
+	var candidates []models.Snippet
+	for _, s := range snippetsOf(lib.live) {
+		if tagFilter == "" || hasTag(s, tagFilter) {
+			candidates = append(candidates, s)
+		}
+	}
+
+	if strings.TrimSpace(query) == "" {
+		sortSnippets(candidates, SortCreated)
+		return candidates, nil
+	}
+
+	terms := parseSearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	type scored struct {
+		snippet models.Snippet
+		score   int
+	}
+	var results []scored
+	for _, s := range candidates {
+		if score := searchScore(s, terms); score > 0 {
+			results = append(results, scored{s, score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].snippet.Pinned != results[j].snippet.Pinned {
+			return results[i].snippet.Pinned
+		}
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].snippet.CreatedAt.After(results[j].snippet.CreatedAt)
+	})
+
+	snippets := make([]models.Snippet, 0, len(results))
+	for _, r := range results {
+		snippets = append(snippets, r.snippet)
+	}
+	return snippets, nil
+}
+
+// FindDuplicates returns snippets outside the trash whose normalized content,
+// or set of files, matches that of s, oldest first
+func (store *FileStore) FindDuplicates(s models.Snippet) ([]models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := snippetHash(s)
+	var duplicates []models.Snippet
+	for _, snippet := range snippetsOf(lib.live) {
+		if snippet.ContentHash == hash {
+			duplicates = append(duplicates, snippet)
+		}
+	}
+	sortOldestFirst(duplicates)
+	return duplicates, nil
+}
+
+// Update rewrites an existing snippet and records the result as a new revision
+func (store *FileStore) Update(s models.Snippet) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := ValidateFiles(s.Files); err != nil {
+		return err
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, s.ID)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", s.ID)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	wasBundle := e.bundle()
+	e.snippet.Title = s.Title
+	e.snippet.Tags = CleanTags(s.Tags)
+	e.snippet.Encrypted = s.Encrypted
+	e.snippet.Language = s.Language
+	e.snippet.Description = s.Description
+	e.snippet.SourceURL = s.SourceURL
+	e.snippet.Content = s.Content
+	e.snippet.Files = s.Files
+	if len(s.Files) > 0 {
+		e.snippet.Content = ""
+	}
+	e.snippet.UpdatedAt = now
+
+	// Switching between one file and a directory moves the snippet
+	if wasBundle != e.bundle() {
+		ext := filepath.Ext(e.path)
+		if ext == "" {
+			ext = extensionFor(e.snippet)
+		}
+		if err := store.moveEntry(e, store.entryPath(e.snippet, ext)); err != nil {
+			return err
+		}
+	} else if err := store.writeEntry(e); err != nil {
+		return err
+	}
+
+	return store.recordRevision(e.snippet, now)
+}
+
+// Move files a snippet in another collection by moving it into that
+// directory. No revision is recorded.
+func (store *FileStore) Move(id int, collection string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	collection, err := CleanCollection(collection)
+	if err != nil {
+		return err
+	}
+	if collection != "" {
+		if err := store.checkCollection(collection); err != nil {
+			return err
+		}
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+	if e.snippet.Collection == collection {
+		return nil
+	}
+
+	e.snippet.Collection = collection
+	return store.moveEntry(e, filepath.Join(store.collectionDir(collection), filepath.Base(e.path)))
+}
+
+// Pin pins or unpins a snippet. The flag is kept in the front matter, but
+// pinning isn't an edit, so no revision is recorded.
+func (store *FileStore) Pin(id int, pinned bool) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+	if e.snippet.Pinned == pinned {
+		return nil
+	}
+
+	e.snippet.Pinned = pinned
+	return store.writeEntry(e)
+}
+
+// Link records that snippet from relates to snippet to in from's front
+// matter, replacing any earlier link between them in that direction
+func (store *FileStore) Link(from, to int, rel string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	rel, err := CheckRelation(rel)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("a snippet cannot be linked to itself")
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e, target := find(lib.live, from), find(lib.live, to)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", from)
+	}
+	if target == nil {
+		return fmt.Errorf("snippet with ID %d not found", to)
+	}
+
+	link := models.Link{UUID: target.snippet.UUID, Rel: rel}
+	for i := range e.links {
+		if e.links[i].UUID == link.UUID {
+			if e.links[i].Rel == rel {
+				return nil
+			}
+			e.links[i] = link
+			return store.writeEntry(e)
+		}
+	}
+	e.links = append(e.links, link)
+	return store.writeEntry(e)
+}
+
+// Unlink removes the link from snippet from to snippet to, if there is one
+func (store *FileStore) Unlink(from, to int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e, target := find(lib.live, from), find(lib.live, to)
+	if e == nil || target == nil {
+		return nil
+	}
+	for i := range e.links {
+		if e.links[i].UUID == target.snippet.UUID {
+			e.links = append(e.links[:i], e.links[i+1:]...)
+			return store.writeEntry(e)
+		}
+	}
+	return nil
+}
+
+// SetMeta sets one metadata field of a snippet in its front matter. Metadata
+// isn't part of the content, so no revision is recorded.
+func (store *FileStore) SetMeta(id int, key string, value string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key, err := CleanMetaKey(key)
+	if err != nil {
+		return err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return fmt.Errorf("no value for '%s'", key)
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+	if e.snippet.Meta[key] == value {
+		return nil
+	}
+
+	if e.snippet.Meta == nil {
+		e.snippet.Meta = make(map[string]string)
+	}
+	e.snippet.Meta[key] = value
+	return store.writeEntry(e)
+}
+
+// UnsetMeta removes one metadata field of a snippet, if it is set
+func (store *FileStore) UnsetMeta(id int, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key, err := CleanMetaKey(key)
+	if err != nil {
+		return err
+	}
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return nil
+	}
+	if _, ok := e.snippet.Meta[key]; !ok {
+		return nil
+	}
+
+	delete(e.snippet.Meta, key)
+	return store.writeEntry(e)
+}
+
+// MarkUsed records that a snippet was just used, bumping its use count
+func (store *FileStore) MarkUsed(id int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	usage := lib.state.Usage[e.snippet.UUID]
+	usage.UseCount++
+	usage.LastUsedAt = formatTimestamp(time.Now())
+	lib.state.Usage[e.snippet.UUID] = usage
+	return store.saveState(lib.state)
+}
+
+// Delete moves a snippet into the trash directory. It stays recoverable
+// with Restore until it is purged.
+func (store *FileStore) Delete(id int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.live, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	e.snippet.DeletedAt = time.Now().UTC().Truncate(time.Second)
+	return store.moveEntry(e, filepath.Join(store.trashDir(), filepath.Base(e.path)))
+}
+
+// Trash returns every trashed snippet, most recently deleted first
+func (store *FileStore) Trash() ([]models.Snippet, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	snippets := snippetsOf(lib.trash)
+	sort.SliceStable(snippets, func(i, j int) bool {
+		return snippets[i].DeletedAt.After(snippets[j].DeletedAt)
+	})
+	return snippets, nil
+}
+
+// Restore moves a snippet out of the trash
+func (store *FileStore) Restore(id int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	e := find(lib.trash, id)
+	if e == nil {
+		return fmt.Errorf("snippet with ID %d is not in the trash", id)
+	}
+
+	// Back where it came from, or unfiled if that collection became a
+	// multi-file snippet in the meantime
+	e.snippet.DeletedAt = time.Time{}
+	if store.checkCollection(e.snippet.Collection) != nil {
+		e.snippet.Collection = ""
+	}
+	return store.moveEntry(e, filepath.Join(store.collectionDir(e.snippet.Collection), filepath.Base(e.path)))
+}
+
+// Purge permanently removes trashed snippets deleted at or before the given
+// time, along with their history, and returns how many were removed
+func (store *FileStore) Purge(before time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	lib, err := store.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, e := range lib.trash {
+		if e.snippet.DeletedAt.After(before) {
+			continue
+		}
+		if err := os.RemoveAll(e.path); err != nil {
+			return purged, err
+		}
+		if err := os.Remove(store.historyPath(e.snippet.UUID)); err != nil && !os.IsNotExist(err) {
+			return purged, err
+		}
+		delete(lib.state.Usage, e.snippet.UUID)
+		purged++
+	}
+
+	return purged, store.saveState(lib.state)
+}
+
+// History returns every revision of a snippet, oldest first
+func (store *FileStore) History(id int) ([]models.Revision, error) {
+	snippet, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := store.readHistory(snippet.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.Revision, 0, len(stored))
+	for _, r := range stored {
+		revision, err := r.revision(id)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, nil
+}
+
+// Revision returns a single revision of a snippet
+func (store *FileStore) Revision(id int, number int) (*models.Revision, error) {
+	store.mu.Lock()
+	lib, err := store.load()
+	store.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := fmt.Errorf("revision %d of snippet %d not found", number, id)
+	e := find(append(lib.live, lib.trash...), id)
+	if e == nil {
+		return nil, notFound
+	}
+
+	stored, err := store.readHistory(e.snippet.UUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range stored {
+		if r.Number == number {
+			return r.revision(id)
+		}
+	}
+	return nil, notFound
+}
+
+// revision converts a stored revision of the snippet with the given ID
+func (r fileRevision) revision(id int) (*models.Revision, error) {
+	createdAt, err := parseTimestamp(r.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp on revision %d of snippet %d: %w", r.Number, id, err)
+	}
+
+	tags := r.Tags
+	if len(tags) == 0 {
+		tags = nil
+	}
+	files := r.Files
+	if len(files) == 0 {
+		files = nil
+	}
+
+	return &models.Revision{
+		SnippetID: id,
+		Number:    r.Number,
+		Title:     r.Title,
+		Tags:      tags,
+		CreatedAt: createdAt,
+		Content:   r.Content,
+		Files:     files,
+	}, nil
+}
+
+// hasTag reports whether a snippet carries tag, ignoring case
+func hasTag(s models.Snippet, tag string) bool {
+	for _, t := range s.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortOldestFirst orders snippets by creation time, then ID
+func sortOldestFirst(snippets []models.Snippet) {
+	sort.SliceStable(snippets, func(i, j int) bool {
+		a, b := snippets[i], snippets[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// sortSnippets orders snippets like the ORDER BY clause of a sort order
+func sortSnippets(snippets []models.Snippet, order SortOrder) error {
+	newer := func(a, b models.Snippet) bool { return a.CreatedAt.After(b.CreatedAt) }
+
+	var less func(a, b models.Snippet) bool
+	switch order {
+	case "", SortCreated:
+		less = newer
+	case SortUpdated:
+		less = func(a, b models.Snippet) bool {
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+			return newer(a, b)
+		}
+	case SortLastUsed:
+		less = func(a, b models.Snippet) bool {
+			if a.LastUsedAt.IsZero() != b.LastUsedAt.IsZero() {
+				return b.LastUsedAt.IsZero()
+			}
+			if !a.LastUsedAt.Equal(b.LastUsedAt) {
+				return a.LastUsedAt.After(b.LastUsedAt)
+			}
+			return newer(a, b)
+		}
+	case SortUseCount:
+		less = func(a, b models.Snippet) bool {
+			if a.UseCount != b.UseCount {
+				return a.UseCount > b.UseCount
+			}
+			if !a.LastUsedAt.Equal(b.LastUsedAt) {
+				return a.LastUsedAt.After(b.LastUsedAt)
+			}
+			return newer(a, b)
+		}
+	case SortTitle:
+		less = func(a, b models.Snippet) bool {
+			if ta, tb := strings.ToLower(a.Title), strings.ToLower(b.Title); ta != tb {
+				return ta < tb
+			}
+			return newer(a, b)
+		}
+	default:
+		return fmt.Errorf("unknown sort order %q", string(order))
+	}
+
+	// Pinned snippets come first whatever the order, as in SQLite
+	sort.SliceStable(snippets, func(i, j int) bool {
+		if snippets[i].Pinned != snippets[j].Pinned {
+			return snippets[i].Pinned
+		}
+		return less(snippets[i], snippets[j])
+	})
+	return nil
+}
+
+// searchTokens splits text into lowercase words the way the FTS5 unicode61
+// tokenizer does
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// countMatches counts where the words of a term appear in order in tokens.
+// For prefix terms the last word only has to start a token.
+func countMatches(tokens []string, term searchTerm) int {
+	words := searchTokens(term.text)
+	if len(words) == 0 {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		match := true
+		for j, word := range words {
+			token := tokens[i+j]
+			if token != word && !(term.prefix && j == len(words)-1 && strings.HasPrefix(token, word)) {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
+
+// searchScore ranks a snippet against every term, weighting title matches
+// over tags and language over description over content. The weights are
+// the bm25 weights in ftsRank (fts.go), 10/5/1/3/5/1 by column; change both
+// together. It is zero unless every term matches somewhere.
+func searchScore(s models.Snippet, terms []searchTerm) int {
+	content := ""
+	if !s.Encrypted {
+		var text strings.Builder
+		text.WriteString(s.Content)
+		for _, file := range s.Files {
+			text.WriteString(" " + file.Name + " " + file.Content)
+		}
+		content = text.String()
+	}
+
+	fields := []struct {
+		tokens []string
+		weight int
+	}{
+		{searchTokens(s.Title), 10},
+		{searchTokens(strings.Join(s.Tags, " ")), 5},
+		{searchTokens(content), 1},
+		{searchTokens(s.Description), 3},
+		{searchTokens(s.Language), 5},
+		{searchTokens(s.SourceURL), 1},
+	}
+
+	score := 0
+	for _, term := range terms {
+		termScore := 0
+		for _, field := range fields {
+			termScore += field.weight * countMatches(field.tokens, term)
+		}
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelimiter opens and closes the YAML header of a snippet file
+const frontMatterDelimiter = "---\n"
+
+// bundleMetaFile holds the front matter of a multi-file snippet, which is
+// stored as a directory of plain files
+const bundleMetaFile = ".snippet.yaml"
+
+// frontMatter is the YAML header of a snippet file. Everything a command
+// can show lives here, except usage counts, which change too often to keep
+// in files under version control.
+type frontMatter struct {
+	Title     string   `yaml:"title"`
+	Tags      []string `yaml:"tags,flow"`
+	Created   string   `yaml:"created"`
+	Updated   string   `yaml:"updated,omitempty"`
+	UUID      string   `yaml:"uuid"`
+	Encrypted bool     `yaml:"encrypted,omitempty"`
+	// Files lists the files of a multi-file snippet in order
+	Files       []string `yaml:"files,omitempty,flow"`
+	Deleted     string   `yaml:"deleted,omitempty"`
+	Language    string   `yaml:"language,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	SourceURL   string   `yaml:"source_url,omitempty"`
+	Pinned      bool     `yaml:"pinned,omitempty"`
+	// Meta holds custom key/value fields
+	Meta map[string]string `yaml:"meta,omitempty"`
+	// Collection is only written for trashed snippets and in synced files;
+	// elsewhere a snippet's directory is its collection
+	Collection string `yaml:"collection,omitempty"`
+	// Links name related snippets by UUID
+	Links []frontMatterLink `yaml:"links,omitempty"`
+}
+
+// frontMatterLink is one link to a related snippet
+type frontMatterLink struct {
+	UUID string `yaml:"uuid"`
+	Rel  string `yaml:"rel"`
+}
+
+// frontMatterLinks converts links for a front matter header
+func frontMatterLinks(links []models.Link) []frontMatterLink {
+	var result []frontMatterLink
+	for _, link := range links {
+		result = append(result, frontMatterLink{UUID: link.UUID, Rel: link.Rel})
+	}
+	return result
+}
+
+// snippetLinks converts the links of a front matter header
+func snippetLinks(links []frontMatterLink) []models.Link {
+	var result []models.Link
+	for _, link := range links {
+		if link.UUID != "" {
+			result = append(result, models.Link{UUID: strings.ToLower(link.UUID), Rel: link.Rel})
+		}
+	}
+	return result
+}
+
+// tagExtensions picks the file extension of a new snippet from its language,
+// or failing that its first tag that names a language
+var tagExtensions = map[string]string{
+	"bash": ".sh", "sh": ".sh", "shell": ".sh", "zsh": ".sh",
+	"c": ".c", "cpp": ".cpp", "c++": ".cpp", "csharp": ".cs", "css": ".css",
+	"go": ".go", "golang": ".go", "html": ".html", "java": ".java",
+	"javascript": ".js", "js": ".js", "json": ".json", "kotlin": ".kt",
+	"lua": ".lua", "markdown": ".md", "md": ".md", "php": ".php",
+	"powershell": ".ps1", "python": ".py", "py": ".py", "ruby": ".rb",
+	"rust": ".rs", "sql": ".sql", "swift": ".swift", "toml": ".toml",
+	"typescript": ".ts", "ts": ".ts", "yaml": ".yaml", "yml": ".yaml",
+}
+
+// extensionFor returns the file extension for a new single-content snippet
+func extensionFor(s models.Snippet) string {
+	for _, tag := range append([]string{s.Language}, s.Tags...) {
+		if ext, ok := tagExtensions[strings.ToLower(tag)]; ok {
+			return ext
+		}
+	}
+	return ".txt"
+}
+
+// splitFrontMatter separates the YAML header of a snippet file from its
+// content. A file without a header is all content.
+func splitFrontMatter(data []byte) (*frontMatter, string, error) {
+	header, content, ok := splitHeader(data)
+	if !ok {
+		return nil, content, nil
+	}
+
+	fm := &frontMatter{}
+	if err := yaml.Unmarshal([]byte(header), fm); err != nil {
+		return nil, "", fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, content, nil
+}
+
+// splitHeader returns the YAML between the front matter delimiters and the
+// content after them. ok is false when data has no header.
+func splitHeader(data []byte) (header string, content string, ok bool) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, frontMatterDelimiter) {
+		return "", text, false
+	}
+
+	rest := text[len(frontMatterDelimiter):]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter)
+	switch {
+	case strings.HasPrefix(rest, frontMatterDelimiter):
+		return "", rest[len(frontMatterDelimiter):], true
+	case end >= 0:
+		return rest[:end+1], rest[end+1+len(frontMatterDelimiter):], true
+	case strings.HasSuffix(rest, "\n---"):
+		return strings.TrimSuffix(rest, "---"), "", true
+	default:
+		return "", text, false
+	}
+}
+
+// renderFrontMatter returns the YAML header followed by content
+func renderFrontMatter(fm *frontMatter, content string) ([]byte, error) {
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter)
+	buf.Write(header)
+	buf.WriteString(frontMatterDelimiter)
+	buf.WriteString(content)
+	return buf.Bytes(), nil
+}
+
+// readBundle reads the front matter and files of a multi-file snippet
+// directory. Files named in the front matter come first, in that order;
+// any others, such as ones added by hand, follow by name.
+func readBundle(dir string) (*frontMatter, []models.File, error) {
+	data, err := os.ReadFile(filepath.Join(dir, bundleMetaFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fm := &frontMatter{}
+	if err := yaml.Unmarshal(data, fm); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", bundleMetaFile, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents := make(map[string]string)
+	var extra []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		contents[name] = string(data)
+		extra = append(extra, name)
+	}
+
+	var files []models.File
+	for _, name := range fm.Files {
+		if content, ok := contents[name]; ok {
+			files = append(files, models.File{Name: name, Content: content})
+			delete(contents, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		if content, ok := contents[name]; ok {
+			files = append(files, models.File{Name: name, Content: content})
+		}
+	}
+
+	return fm, files, nil
+}
+
+// writeBundle writes a multi-file snippet directory, removing files that
+// are no longer part of the snippet
+func writeBundle(dir string, fm *frontMatter, files []models.File) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	fm.Files = nil
+	for _, file := range files {
+		if err := writeFileAtomic(filepath.Join(dir, file.Name), []byte(file.Content)); err != nil {
+			return err
+		}
+		keep[file.Name] = true
+		fm.Files = append(fm.Files, file.Name)
+	}
+
+	meta, err := yaml.Marshal(fm)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, bundleMetaFile), meta); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || keep[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path in one step, so a crash never
+// leaves a snippet half written. The temporary file is hidden, so it is
+// never mistaken for a snippet.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
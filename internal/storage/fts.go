@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// ftsRank orders full-text matches best first. bm25 weights are given per
+// column of snippets_fts (title, tags, content, description, language,
+// source_url), so a hit in the title counts for far more than the same hit
+// buried in the content. searchScore in filestore.go uses the same weights.
+const ftsRank = `bm25(snippets_fts, 10.0, 5.0, 1.0, 3.0, 5.0, 1.0)`
+
+// ftsTagsFor renders the tags of the snippet identified by idExpr as space
+// separated text for indexing. Released migrations spell it out instead, so
+// changing it can't change them.
+func ftsTagsFor(idExpr string) string {
+	return `COALESCE((SELECT group_concat(t.name, ' ')
+		FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+		WHERE st.snippet_id = ` + idExpr + `), '')`
+}
+
+// createFTS creates the snippets_fts index, the triggers that keep it in sync
+// with snippets and snippet_tags, and indexes every existing snippet
+func createFTS(tx *sql.Tx) error {
+	return execStatements(
+		`CREATE VIRTUAL TABLE snippets_fts USING fts5(
+			title, tags, content,
+			tokenize = 'unicode61 remove_diacritics 2',
+			prefix = '2 3'
+		)`,
+		`CREATE TRIGGER snippets_fts_insert AFTER INSERT ON snippets BEGIN
+			INSERT INTO snippets_fts (rowid, title, tags, content)
+			VALUES (new.id, COALESCE(new.title, ''), '', COALESCE(new.content, ''));
+		END`,
+		`CREATE TRIGGER snippets_fts_update AFTER UPDATE OF title, content ON snippets BEGIN
+			UPDATE snippets_fts SET title = COALESCE(new.title, ''), content = COALESCE(new.content, '')
+			WHERE rowid = new.id;
+		END`,
+		`CREATE TRIGGER snippets_fts_delete AFTER DELETE ON snippets BEGIN
+			DELETE FROM snippets_fts WHERE rowid = old.id;
+		END`,
+		`CREATE TRIGGER snippets_fts_tag_insert AFTER INSERT ON snippet_tags BEGIN
+			UPDATE snippets_fts SET tags = COALESCE((SELECT group_concat(t.name, ' ')
+				FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = new.snippet_id), '')
+			WHERE rowid = new.snippet_id;
+		END`,
+		`CREATE TRIGGER snippets_fts_tag_delete AFTER DELETE ON snippet_tags BEGIN
+			UPDATE snippets_fts SET tags = COALESCE((SELECT group_concat(t.name, ' ')
+				FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = old.snippet_id), '')
+			WHERE rowid = old.snippet_id;
+		END`,
+		`INSERT INTO snippets_fts (rowid, title, tags, content)
+			SELECT s.id, COALESCE(s.title, ''), COALESCE((SELECT group_concat(t.name, ' ')
+				FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = s.id), ''), COALESCE(s.content, '')
+			FROM snippets s`,
+	)(tx)
+}
+
+// searchTerm is one term of a search query: a word or a quoted phrase.
+// Prefix terms also match tokens that merely start with their last word.
+type searchTerm struct {
+	text   string
+	prefix bool
+}
+
+// parseSearchTerms splits user input into search terms. Bare words match
+// any token they are a prefix of, so "func" finds "function"; "double
+// quoted" text is matched as an exact phrase, or as a prefix phrase when
+// followed by *. An unterminated quote runs to the end of the input.
+func parseSearchTerms(input string) []searchTerm {
+	var terms []searchTerm
+	var current strings.Builder
+	inPhrase := false
+
+	flush := func(prefix bool) {
+		text := strings.TrimSpace(strings.TrimRight(current.String(), "*"))
+		current.Reset()
+		if text == "" {
+			return
+		}
+		terms = append(terms, searchTerm{text: text, prefix: prefix})
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '"' && inPhrase:
+			prefix := i+1 < len(runes) && runes[i+1] == '*'
+			if prefix {
+				i++
+			}
+			flush(prefix)
+			inPhrase = false
+		case r == '"':
+			flush(true)
+			inPhrase = true
+		case unicode.IsSpace(r) && !inPhrase:
+			flush(true)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(!inPhrase)
+
+	return terms
+}
+
+// ftsQuery turns user input into an FTS5 MATCH expression in which every
+// term must match. Everything is quoted, so FTS5 operators and punctuation
+// in the input can never cause a syntax error.
+func ftsQuery(input string) string {
+	var terms []string
+	for _, t := range parseSearchTerms(input) {
+		term := `"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`
+		if t.prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
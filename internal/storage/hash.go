@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// ContentHash returns the hash used to detect duplicate snippets. Content is
+// normalized first so that line endings, trailing whitespace and surrounding
+// blank lines don't make otherwise identical snippets look different.
+func ContentHash(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	normalized := strings.Trim(strings.Join(lines, "\n"), "\n")
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// releasedContentHash is ContentHash as migration 7 was released with. The
+// migration must keep hashing this way even if ContentHash changes; a new
+// normalization needs a new migration that rehashes every snippet.
+func releasedContentHash(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	normalized := strings.Trim(strings.Join(lines, "\n"), "\n")
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// addContentHash adds the content_hash column and fills it in for every
+// existing snippet
+func addContentHash(tx *sql.Tx) error {
+	err := execStatements(
+		`ALTER TABLE snippets ADD COLUMN content_hash TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX idx_snippets_content_hash ON snippets(content_hash)`,
+	)(tx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, COALESCE(content, '') FROM snippets`)
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes[id] = releasedContentHash(content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, hash := range hashes {
+		if _, err := tx.Exec(`UPDATE snippets SET content_hash = ? WHERE id = ?`, hash, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindDuplicates returns snippets outside the trash whose normalized content,
+// or set of files, matches that of s, oldest first
+func (store *SQLiteStore) FindDuplicates(s models.Snippet) ([]models.Snippet, error) {
+	return store.querySnippets(`SELECT `+snippetColumns+` FROM snippets s
+		WHERE s.content_hash = ? AND s.deleted_at IS NULL ORDER BY s.created_at, s.id`, snippetHash(s))
+}
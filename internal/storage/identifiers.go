@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// maxSlugLength keeps slugs short enough to type
+const maxSlugLength = 50
+
+// nonSlugChars matches runs of characters that are replaced by a dash
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify derives a slug from a title: lowercase letters and digits joined
+// by dashes. Slugs never consist of digits only, so they can't be mistaken
+// for a numeric ID.
+func Slugify(title string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+
+	if slug == "" {
+		return "snippet"
+	}
+	if _, err := strconv.Atoi(slug); err == nil {
+		return "snippet-" + slug
+	}
+	return slug
+}
+
+// uniqueSlug returns base, or base with the lowest free numeric suffix when
+// another snippet already uses it
+func uniqueSlug(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, base string) (string, error) {
+	slug := base
+	for n := 2; ; n++ {
+		var exists bool
+		if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM snippets WHERE slug = ?)`, slug).Scan(&exists); err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// assignIdentifiers fills in a new snippet's UUID and slug. A UUID supplied
+// by the caller, e.g. from an import, is kept and must not be in use; a
+// supplied slug is kept unless another snippet already has it.
+func assignIdentifiers(tx *sql.Tx, s *models.Snippet) error {
+	if s.UUID == "" {
+		s.UUID = uuid.NewString()
+	} else {
+		parsed, err := uuid.Parse(s.UUID)
+		if err != nil {
+			return fmt.Errorf("invalid UUID '%s': %w", s.UUID, err)
+		}
+		s.UUID = parsed.String()
+
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM snippets WHERE uuid = ?)`, s.UUID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("a snippet with UUID %s already exists", s.UUID)
+		}
+	}
+
+	base := Slugify(s.Slug)
+	if s.Slug == "" {
+		base = Slugify(s.Title)
+	}
+	slug, err := uniqueSlug(tx, base)
+	if err != nil {
+		return err
+	}
+	s.Slug = slug
+
+	return nil
+}
+
+// addIdentifiers gives every existing snippet a UUID and a unique slug
+func addIdentifiers(tx *sql.Tx) error {
+	err := execStatements(
+		`ALTER TABLE snippets ADD COLUMN uuid TEXT`,
+		`ALTER TABLE snippets ADD COLUMN slug TEXT`,
+	)(tx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, COALESCE(title, '') FROM snippets ORDER BY id`)
+	if err != nil {
+		return err
+	}
+
+	var snippets []models.Snippet
+	for rows.Next() {
+		var s models.Snippet
+		if err := rows.Scan(&s.ID, &s.Title); err != nil {
+			rows.Close()
+			return err
+		}
+		snippets = append(snippets, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Oldest snippets get first pick of slugs. Slugs are derived the way
+	// Slugify and uniqueSlug did when this migration was released; it keeps
+	// its own copy so that changing them can't change it.
+	nonSlug := regexp.MustCompile(`[^a-z0-9]+`)
+	for _, s := range snippets {
+		base := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s.Title), "-"), "-")
+		if len(base) > 50 {
+			base = strings.TrimRight(base[:50], "-")
+		}
+		if base == "" {
+			base = "snippet"
+		} else if _, err := strconv.Atoi(base); err == nil {
+			base = "snippet-" + base
+		}
+
+		slug := base
+		for n := 2; ; n++ {
+			var exists bool
+			if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM snippets WHERE slug = ?)`, slug).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				break
+			}
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		if _, err := tx.Exec(`UPDATE snippets SET uuid = ?, slug = ? WHERE id = ?`, uuid.NewString(), slug, s.ID); err != nil {
+			return err
+		}
+	}
+
+	return execStatements(
+		`CREATE UNIQUE INDEX idx_snippets_uuid ON snippets(uuid)`,
+		`CREATE UNIQUE INDEX idx_snippets_slug ON snippets(slug)`,
+	)(tx)
+}
+
+// ErrNotFound is returned by Lookup when no snippet has the given identifier
+var ErrNotFound = errors.New("snippet not found")
+
+// Lookup returns the snippet outside the trash identified by ref, which may
+// be a numeric ID, a UUID or a slug
+func (store *SQLiteStore) Lookup(ref string) (*models.Snippet, error) {
+	// IDs start at 1, so a ref that isn't a number matches no ID
+	id, _ := strconv.Atoi(ref)
+
+	row := store.conn().QueryRow(`SELECT `+snippetColumns+` FROM snippets s
+		WHERE (s.id = ? OR s.uuid = ? OR s.slug = ?) AND s.deleted_at IS NULL`, id, strings.ToLower(ref), ref)
+
+	s, err := scanSnippet(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return s, err
+}
+
+// FindByTitle returns snippets outside the trash whose title equals title,
+// ignoring case. When there are none, it returns those whose title or slug
+// starts with title instead, oldest first.
+func (store *SQLiteStore) FindByTitle(title string) ([]models.Snippet, error) {
+	exact, err := store.querySnippets(`SELECT `+snippetColumns+` FROM snippets s
+		WHERE s.title = ? COLLATE NOCASE AND s.deleted_at IS NULL ORDER BY s.created_at, s.id`, title)
+	if err != nil || len(exact) > 0 {
+		return exact, err
+	}
+
+	prefix := likeEscaper.Replace(title) + "%"
+	return store.querySnippets(`SELECT `+snippetColumns+` FROM snippets s
+		WHERE (s.title LIKE ? ESCAPE '\' OR s.slug LIKE ? ESCAPE '\') AND s.deleted_at IS NULL
+		ORDER BY s.created_at, s.id`, prefix, prefix)
+}
+
+// likeEscaper escapes LIKE wildcards so user input matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
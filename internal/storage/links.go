@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// Relations between linked snippets
+const (
+	LinkSeeAlso   = "see-also"
+	LinkDependsOn = "depends-on"
+)
+
+// LinkRelations lists every supported relation, for help text and validation
+var LinkRelations = []string{LinkSeeAlso, LinkDependsOn}
+
+// CheckRelation returns rel in canonical form, or an error if it isn't one
+// of LinkRelations. "" means see-also.
+func CheckRelation(rel string) (string, error) {
+	rel = strings.ToLower(strings.TrimSpace(rel))
+	if rel == "" {
+		return LinkSeeAlso, nil
+	}
+	for _, known := range LinkRelations {
+		if rel == known {
+			return rel, nil
+		}
+	}
+	return "", fmt.Errorf("unknown relation '%s'. Use: %s", rel, strings.Join(LinkRelations, ", "))
+}
+
+// linksColumn and backlinksColumn select the links from and to the current
+// snippet as JSON arrays, leaving out snippets in the trash
+const (
+	linksColumn = `(SELECT json_group_array(json_object('Rel', l.rel, 'UUID', COALESCE(o.uuid, ''), 'ID', o.id, 'Title', o.title)
+		ORDER BY l.created_at, o.id)
+	FROM snippet_links l JOIN snippets o ON o.id = l.target_id
+	WHERE l.snippet_id = s.id AND o.deleted_at IS NULL)`
+	backlinksColumn = `(SELECT json_group_array(json_object('Rel', l.rel, 'UUID', COALESCE(o.uuid, ''), 'ID', o.id, 'Title', o.title)
+		ORDER BY l.created_at, o.id)
+	FROM snippet_links l JOIN snippets o ON o.id = l.snippet_id
+	WHERE l.target_id = s.id AND o.deleted_at IS NULL)`
+)
+
+// createLinks adds the snippet_links table. A pair of snippets has at most
+// one link in each direction.
+func createLinks(tx *sql.Tx) error {
+	return execStatements(
+		`CREATE TABLE snippet_links (
+			snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			target_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			rel TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (snippet_id, target_id),
+			CHECK (snippet_id != target_id)
+		)`,
+		`CREATE INDEX idx_snippet_links_target ON snippet_links(target_id)`,
+	)(tx)
+}
+
+// decodeLinks parses the JSON array produced by linksColumn or backlinksColumn
+func decodeLinks(linksJSON string) ([]models.Link, error) {
+	// models.Link leaves the ID out of JSON exports, so it's read separately
+	var rows []struct {
+		Rel   string
+		UUID  string
+		ID    int
+		Title string
+	}
+	if linksJSON == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(linksJSON), &rows); err != nil {
+		return nil, err
+	}
+
+	var links []models.Link
+	for _, row := range rows {
+		links = append(links, models.Link{Rel: row.Rel, UUID: row.UUID, ID: row.ID, Title: row.Title})
+	}
+	return links, nil
+}
+
+// Link records that snippet from relates to snippet to, replacing any
+// earlier link between them in that direction
+func (store *SQLiteStore) Link(from, to int, rel string) error {
+	rel, err := CheckRelation(rel)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("a snippet cannot be linked to itself")
+	}
+
+	tx, err := store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range []int{from, to} {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM snippets WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("snippet with ID %d not found", id)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO snippet_links (snippet_id, target_id, rel, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (snippet_id, target_id) DO UPDATE SET rel = excluded.rel`,
+		from, to, rel, formatTimestamp(time.Now()))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Unlink removes the link from snippet from to snippet to, if there is one
+func (store *SQLiteStore) Unlink(from, to int) error {
+	_, err := store.exec(`DELETE FROM snippet_links WHERE snippet_id = ? AND target_id = ?`, from, to)
+	return err
+}
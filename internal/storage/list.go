@@ -0,0 +1,54 @@
+package storage
+
+import "fmt"
+
+// SortOrder selects the order List returns snippets in
+type SortOrder string
+
+const (
+	SortCreated  SortOrder = "created"
+	SortUpdated  SortOrder = "updated"
+	SortLastUsed SortOrder = "used"
+	SortUseCount SortOrder = "uses"
+	SortTitle    SortOrder = "title"
+)
+
+// SortOrders lists every supported sort order, for help text and validation
+var SortOrders = []SortOrder{SortCreated, SortUpdated, SortLastUsed, SortUseCount, SortTitle}
+
+// ListOptions controls which snippets List returns and in what order
+type ListOptions struct {
+	// Sort defaults to SortCreated, newest first
+	Sort SortOrder
+	// Collection limits the list to one collection; "" lists every snippet
+	Collection string
+	// Recursive also lists the collections nested in Collection
+	Recursive bool
+	// Pinned limits the list to pinned snippets
+	Pinned bool
+	// Meta limits the list to snippets with all of these metadata values
+	Meta map[string]string
+}
+
+// pinnedFirst is prepended to every ORDER BY, so pinned snippets always
+// come before the rest
+const pinnedFirst = "s.pinned DESC, "
+
+// orderBy returns the ORDER BY clause for a sort order
+func (o SortOrder) orderBy() (string, error) {
+	switch o {
+	case "", SortCreated:
+		return "s.created_at DESC", nil
+	case SortUpdated:
+		return "s.updated_at DESC, s.created_at DESC", nil
+	case SortLastUsed:
+		// Never-used snippets go last
+		return "s.last_used_at IS NULL, s.last_used_at DESC, s.created_at DESC", nil
+	case SortUseCount:
+		return "s.use_count DESC, s.last_used_at DESC, s.created_at DESC", nil
+	case SortTitle:
+		return "s.title COLLATE NOCASE, s.created_at DESC", nil
+	default:
+		return "", fmt.Errorf("unknown sort order %q", string(o))
+	}
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// DBInfo summarizes a database file and what it holds
+type DBInfo struct {
+	Path          string
+	Size          int64
+	SchemaVersion int
+	Snippets      int
+	Trashed       int
+	Tags          int
+	Files         int
+	Revisions     int
+	FreePages     int
+	PageSize      int
+}
+
+// CheckResult lists the problems found by Check. Repairable problems are
+// those that Repair can fix by rebuilding derived data.
+type CheckResult struct {
+	Problems   []string
+	Repairable []string
+}
+
+// OK reports whether the check found nothing wrong
+func (r *CheckResult) OK() bool {
+	return len(r.Problems) == 0 && len(r.Repairable) == 0
+}
+
+// Path returns the path of the database file, or ":memory:"
+func (store *SQLiteStore) Path() string {
+	return store.path
+}
+
+// Info returns the size, schema version and row counts of the database
+func (store *SQLiteStore) Info() (*DBInfo, error) {
+	info := &DBInfo{Path: store.path}
+
+	// Recent writes live in the write-ahead log until it is checkpointed
+	for _, path := range []string{store.path, store.path + "-wal"} {
+		if stat, err := os.Stat(path); err == nil {
+			info.Size += stat.Size()
+		}
+	}
+
+	version, err := store.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	info.SchemaVersion = version
+
+	for _, count := range []struct {
+		dest  *int
+		query string
+	}{
+		{&info.Snippets, `SELECT COUNT(*) FROM snippets WHERE deleted_at IS NULL`},
+		{&info.Trashed, `SELECT COUNT(*) FROM snippets WHERE deleted_at IS NOT NULL`},
+		{&info.Tags, `SELECT COUNT(*) FROM tags`},
+		{&info.Files, `SELECT COUNT(*) FROM snippet_files`},
+		{&info.Revisions, `SELECT COUNT(*) FROM snippet_revisions`},
+		{&info.FreePages, `PRAGMA freelist_count`},
+		{&info.PageSize, `PRAGMA page_size`},
+	} {
+		if err := store.conn().QueryRow(count.query).Scan(count.dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+// Check runs SQLite's integrity and foreign key checks and verifies that
+// tags and the search index agree with the snippets they are derived from
+func (store *SQLiteStore) Check() (*CheckResult, error) {
+	result := &CheckResult{}
+
+	rows, err := store.conn().Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if msg != "ok" {
+			result.Problems = append(result.Problems, msg)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var violations int
+	if err := store.conn().QueryRow(`SELECT COUNT(*) FROM pragma_foreign_key_check`).Scan(&violations); err != nil {
+		return nil, err
+	}
+	if violations > 0 {
+		result.Problems = append(result.Problems, fmt.Sprintf("%d row(s) reference missing snippets or tags", violations))
+	}
+
+	if _, err := store.conn().Exec(`INSERT INTO snippets_fts (snippets_fts) VALUES ('integrity-check')`); err != nil {
+		result.Repairable = append(result.Repairable, "search index is corrupt: "+err.Error())
+	}
+
+	// Older triggers indexed content without the trailing file separator, so
+	// content is compared without trailing whitespace
+	for _, check := range []struct {
+		query   string
+		problem string
+	}{
+		{`SELECT COUNT(*) FROM tags WHERE id NOT IN (SELECT tag_id FROM snippet_tags)`,
+			"%d tag(s) are not used by any snippet"},
+		{`SELECT COUNT(*) FROM snippets s WHERE s.id NOT IN (SELECT rowid FROM snippets_fts)`,
+			"%d snippet(s) are missing from the search index"},
+		{`SELECT COUNT(*) FROM snippets_fts WHERE rowid NOT IN (SELECT id FROM snippets)`,
+			"%d search index entries belong to no snippet"},
+		{`SELECT COUNT(*) FROM snippets s JOIN snippets_fts f ON f.rowid = s.id
+			WHERE f.title != COALESCE(s.title, '')
+				OR f.tags != ` + ftsTagsFor("s.id") + `
+				OR rtrim(f.content) != rtrim(` + ftsContentOf("s.id") + `)
+				OR f.description != s.description OR f.language != s.language OR f.source_url != s.source_url`,
+			"%d search index entries are out of date"},
+	} {
+		var n int
+		if err := store.conn().QueryRow(check.query).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			result.Repairable = append(result.Repairable, fmt.Sprintf(check.problem, n))
+		}
+	}
+
+	return result, nil
+}
+
+// Repair rebuilds the search index from the snippets table and removes
+// unused tags
+func (store *SQLiteStore) Repair() error {
+	tx, err := store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = execStatements(
+		`DELETE FROM snippets_fts`,
+		ftsReindex(),
+	)(tx.Tx)
+	if err != nil {
+		return err
+	}
+
+	if err := pruneTags(tx.Tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Vacuum rebuilds the database file, returning unused pages to the
+// file system, and empties the write-ahead log
+func (store *SQLiteStore) Vacuum() error {
+	if _, err := store.exec(`VACUUM`); err != nil {
+		return err
+	}
+	_, err := store.exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
+
+// Backup copies the database to path with SQLite's online backup API, so
+// the copy is consistent even while other processes are writing. Any file
+// at path is replaced.
+func (store *SQLiteStore) Backup(path string) error {
+	return store.withBackupConn(func(conn backupConn) (*sqlite.Backup, error) {
+		return conn.NewBackup(path)
+	})
+}
+
+// RestoreFrom replaces the contents of the database with the snip database
+// at path and brings its schema up to date
+func (store *SQLiteStore) RestoreFrom(path string) error {
+	if err := validateBackup(path); err != nil {
+		return err
+	}
+
+	store.writeMu.Lock()
+	err := store.withBackupConn(func(conn backupConn) (*sqlite.Backup, error) {
+		return conn.NewRestore(path)
+	})
+	store.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	_, err = migrate(store.db)
+	return err
+}
+
+// backupConn is the part of the driver connection that runs backups
+type backupConn interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+// withBackupConn starts a backup on a raw driver connection and copies
+// every page in one step
+func (store *SQLiteStore) withBackupConn(start func(conn backupConn) (*sqlite.Backup, error)) error {
+	conn, err := store.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		bc, ok := driverConn.(backupConn)
+		if !ok {
+			return fmt.Errorf("the SQLite driver does not support backups")
+		}
+
+		backup, err := start(bc)
+		if err != nil {
+			return err
+		}
+
+		if _, err := backup.Step(-1); err != nil {
+			backup.Finish()
+			return err
+		}
+		return backup.Finish()
+	})
+}
+
+// validateBackup checks that path holds an intact snip database whose
+// schema this version of snip understands
+func validateBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var tables int
+	err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'snippets'`).Scan(&tables)
+	if err != nil || tables == 0 {
+		return fmt.Errorf("%s is not a snip database", path)
+	}
+
+	// Databases made before schema versioning have no schema_version table
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil && !strings.Contains(err.Error(), "no such table") {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if int(version.Int64) > latest {
+		return fmt.Errorf("%s has schema version %d, which is newer than this version of snip supports (%d)", path, version.Int64, latest)
+	}
+
+	var result string
+	if err := db.QueryRow(`PRAGMA quick_check`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("%s is corrupt: %s", path, result)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// metaColumn selects the metadata of the current snippet as a JSON object
+const metaColumn = `(SELECT json_group_object(m.key, m.value) FROM snippet_meta m WHERE m.snippet_id = s.id)`
+
+// createMeta adds the snippet_meta table for custom key/value metadata
+func createMeta(tx *sql.Tx) error {
+	return execStatements(
+		`CREATE TABLE snippet_meta (
+			snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (snippet_id, key)
+		)`,
+		`CREATE INDEX idx_snippet_meta_key ON snippet_meta(key, value)`,
+	)(tx)
+}
+
+// CleanMetaKey returns a metadata key in canonical, lowercase form. Keys
+// are made of letters, digits, '.', '_' and '-'.
+func CleanMetaKey(key string) (string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" {
+		return "", fmt.Errorf("metadata keys cannot be empty")
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return "", fmt.Errorf("invalid metadata key '%s'. Use letters, digits, '.', '_' and '-'", key)
+		}
+	}
+	return key, nil
+}
+
+// ParseMeta splits a "key=value" pair, such as "env=prod"
+func ParseMeta(pair string) (key string, value string, err error) {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected key=value, got '%s'", pair)
+	}
+	if key, err = CleanMetaKey(key); err != nil {
+		return "", "", err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", fmt.Errorf("no value for '%s'", key)
+	}
+	return key, value, nil
+}
+
+// CleanMeta checks every key of meta and trims the values, dropping empty
+// ones. Keys that differ only in case are merged.
+func CleanMeta(meta map[string]string) (map[string]string, error) {
+	var cleaned map[string]string
+	for key, value := range meta {
+		key, err := CleanMetaKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if cleaned == nil {
+			cleaned = make(map[string]string)
+		}
+		cleaned[key] = value
+	}
+	return cleaned, nil
+}
+
+// HasMeta reports whether a snippet with the given metadata is listed by
+// opts: it must carry every key and value in opts.Meta
+func (opts ListOptions) HasMeta(meta map[string]string) bool {
+	for key, value := range opts.Meta {
+		if meta[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// metaConditions returns the WHERE conditions and parameters that HasMeta
+// applies in SQL
+func (opts ListOptions) metaConditions() ([]string, []any) {
+	var conditions []string
+	var params []any
+	for key, value := range opts.Meta {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM snippet_meta m WHERE m.snippet_id = s.id AND m.key = ? AND m.value = ?)`)
+		params = append(params, key, value)
+	}
+	return conditions, params
+}
+
+// decodeMeta parses the JSON object produced by metaColumn
+func decodeMeta(metaJSON string) (map[string]string, error) {
+	var meta map[string]string
+	if metaJSON == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(metaJSON), &meta); err != nil {
+		return nil, err
+	}
+	if len(meta) == 0 {
+		return nil, nil
+	}
+	return meta, nil
+}
+
+// setMeta stores the metadata of a new snippet
+func setMeta(tx *sql.Tx, snippetID int64, meta map[string]string) error {
+	for key, value := range meta {
+		if _, err := tx.Exec(`INSERT INTO snippet_meta (snippet_id, key, value) VALUES (?, ?, ?)`, snippetID, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMeta sets one metadata field of a snippet. Metadata isn't part of the
+// content, so no revision is recorded.
+func (store *SQLiteStore) SetMeta(id int, key string, value string) error {
+	key, err := CleanMetaKey(key)
+	if err != nil {
+		return err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return fmt.Errorf("no value for '%s'", key)
+	}
+
+	tx, err := store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM snippets WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	_, err = tx.Exec(`INSERT INTO snippet_meta (snippet_id, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (snippet_id, key) DO UPDATE SET value = excluded.value`, id, key, value)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UnsetMeta removes one metadata field of a snippet, if it is set
+func (store *SQLiteStore) UnsetMeta(id int, key string) error {
+	key, err := CleanMetaKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = store.exec(`DELETE FROM snippet_meta WHERE snippet_id = ? AND key = ?`, id, key)
+	return err
+}
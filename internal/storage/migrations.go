@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration is a single, ordered schema change. Migrations are append-only:
+// once a version has been released its body must never change, otherwise
+// databases that already applied it will silently diverge.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// MigrationInfo describes a known migration and whether it has been applied
+type MigrationInfo struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrations lists every schema change in the order it must be applied.
+// Add new entries to the end with the next version number.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create snippets table",
+		up: execStatements(`CREATE TABLE IF NOT EXISTS snippets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT,
+			tags TEXT,
+			content TEXT,
+			created_at DATETIME
+		)`),
+	},
+	{
+		version: 2,
+		name:    "normalize tags into tags and snippet_tags",
+		up:      normalizeTags,
+	},
+	{
+		version: 3,
+		name:    "add snippets_fts full-text index",
+		up:      createFTS,
+	},
+	{
+		version: 4,
+		name:    "add snippet_revisions history",
+		up:      createRevisions,
+	},
+	{
+		version: 5,
+		name:    "add deleted_at for the trash bin",
+		up: execStatements(
+			`ALTER TABLE snippets ADD COLUMN deleted_at TEXT`,
+			`CREATE INDEX idx_snippets_deleted_at ON snippets(deleted_at)`,
+		),
+	},
+	{
+		version: 6,
+		name:    "track updated_at, last_used_at and use_count",
+		up: execStatements(
+			`ALTER TABLE snippets ADD COLUMN updated_at TEXT`,
+			`ALTER TABLE snippets ADD COLUMN last_used_at TEXT`,
+			`ALTER TABLE snippets ADD COLUMN use_count INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE snippets SET updated_at = created_at`,
+		),
+	},
+	{
+		version: 7,
+		name:    "add content_hash for duplicate detection",
+		up:      addContentHash,
+	},
+	{
+		version: 8,
+		name:    "store timestamps as UTC RFC3339",
+		up:      normalizeTimestamps,
+	},
+	{
+		version: 9,
+		name:    "add encrypted flag and keep ciphertext out of the search index",
+		up:      addEncrypted,
+	},
+	{
+		version: 10,
+		name:    "add snippet_files for multi-file snippets",
+		up:      createFiles,
+	},
+	{
+		version: 11,
+		name:    "add uuid and slug identifiers",
+		up:      addIdentifiers,
+	},
+	{
+		version: 12,
+		name:    "add language, description and source_url",
+		up:      addDetails,
+	},
+	{
+		version: 13,
+		name:    "add collection for nested collections",
+		up: execStatements(
+			`ALTER TABLE snippets ADD COLUMN collection TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_snippets_collection ON snippets(collection)`,
+		),
+	},
+	{
+		version: 14,
+		name:    "add pinned flag",
+		up:      execStatements(`ALTER TABLE snippets ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`),
+	},
+	{
+		version: 15,
+		name:    "add snippet_links for related snippets",
+		up:      createLinks,
+	},
+	{
+		version: 16,
+		name:    "add snippet_meta for custom metadata",
+		up:      createMeta,
+	},
+}
+
+// execStatements returns a migration body that runs each statement in order
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// normalizeTags moves the comma-joined snippets.tags column into the tags and
+// snippet_tags tables and then drops the old column
+func normalizeTags(tx *sql.Tx) error {
+	err := execStatements(
+		`CREATE TABLE tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE
+		)`,
+		`CREATE TABLE snippet_tags (
+			snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (snippet_id, tag_id)
+		)`,
+		`CREATE INDEX idx_snippet_tags_tag_id ON snippet_tags(tag_id)`,
+	)(tx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, COALESCE(tags, '') FROM snippets`)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[int64][]string)
+	for rows.Next() {
+		var id int64
+		var tagsStr string
+		if err := rows.Scan(&id, &tagsStr); err != nil {
+			rows.Close()
+			return err
+		}
+		if tagsStr != "" {
+			existing[id] = strings.Split(tagsStr, ",")
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Tags are trimmed and deduplicated ignoring case, keeping the first
+	// spelling, as when this migration was released. It doesn't share
+	// CleanTags and setTags, so changes to those can't change it.
+	for id, tags := range existing {
+		seen := make(map[string]bool)
+		position := 0
+		for _, tag := range tags {
+			tag = strings.TrimSpace(tag)
+			key := strings.ToLower(tag)
+			if tag == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if _, err := tx.Exec(`INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, tag); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO snippet_tags (snippet_id, tag_id, position)
+				SELECT ?, id, ? FROM tags WHERE name = ?`, id, position, tag)
+			if err != nil {
+				return err
+			}
+			position++
+		}
+	}
+
+	_, err = tx.Exec(`ALTER TABLE snippets DROP COLUMN tags`)
+	return err
+}
+
+// ensureSchemaVersionTable creates the bookkeeping table used to track
+// which migrations have been applied
+func ensureSchemaVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	return err
+}
+
+// appliedMigrations returns the applied_at time of every applied migration keyed by version
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAtStr string
+		if err := rows.Scan(&version, &appliedAtStr); err != nil {
+			return nil, err
+		}
+
+		appliedAt, err := parseTimestamp(appliedAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid applied_at for schema version %d: %w", version, err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// migrate applies every pending migration in order, each in its own
+// transaction, and returns the migrations that were applied
+func migrate(db *sql.DB) ([]MigrationInfo, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	for version := range applied {
+		if version > latest {
+			return nil, fmt.Errorf("database schema version %d is newer than this version of snip supports (%d); please upgrade snip", version, latest)
+		}
+	}
+
+	var done []MigrationInfo
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		appliedAt := time.Now().UTC()
+		ran, err := applyMigration(db, m, appliedAt)
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		if !ran {
+			continue
+		}
+
+		done = append(done, MigrationInfo{
+			Version:   m.version,
+			Name:      m.name,
+			Applied:   true,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return done, nil
+}
+
+// applyMigration runs a single migration and records it atomically. It
+// reports false without changing anything when another process applied the
+// migration first.
+func applyMigration(db *sql.DB, m migration, appliedAt time.Time) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Transactions take the write lock up front, so this can't race
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_version WHERE version = ?)`, m.version).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := m.up(tx); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, formatTimestamp(appliedAt))
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// migrationStatus reports every known migration and whether it has been applied
+func migrationStatus(db *sql.DB) ([]MigrationInfo, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]MigrationInfo, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		infos = append(infos, MigrationInfo{
+			Version:   m.version,
+			Name:      m.name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return infos, nil
+}
+
+// Migrate applies any pending schema migrations and returns the ones applied
+func (store *SQLiteStore) Migrate() ([]MigrationInfo, error) {
+	return migrate(store.db)
+}
+
+// MigrationStatus returns every known migration and whether it has been applied
+func (store *SQLiteStore) MigrationStatus() ([]MigrationInfo, error) {
+	return migrationStatus(store.db)
+}
+
+// SchemaVersion returns the highest applied migration version
+func (store *SQLiteStore) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	err := store.conn().QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
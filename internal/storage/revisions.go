@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// createRevisions adds the snippet_revisions table and records the current
+// state of every existing snippet as its first revision. created_at is
+// copied as stored; migration 8 converts it along with every other
+// timestamp.
+func createRevisions(tx *sql.Tx) error {
+	return execStatements(
+		`CREATE TABLE snippet_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+			revision INTEGER NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			tags TEXT NOT NULL DEFAULT '[]',
+			content TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			UNIQUE (snippet_id, revision)
+		)`,
+		`INSERT INTO snippet_revisions (snippet_id, revision, title, tags, content, created_at)
+			SELECT s.id, 1, COALESCE(s.title, ''),
+				(SELECT json_group_array(t.name ORDER BY st.position)
+					FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+					WHERE st.snippet_id = s.id),
+				COALESCE(s.content, ''), COALESCE(s.created_at, '')
+			FROM snippets s`,
+	)(tx)
+}
+
+// recordRevision stores the given state of a snippet as its next revision
+func recordRevision(tx *sql.Tx, snippetID int64, s models.Snippet, at time.Time) error {
+	tagsJSON, err := json.Marshal(CleanTags(s.Tags))
+	if err != nil {
+		return err
+	}
+
+	filesJSON, err := encodeFiles(s.Files)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO snippet_revisions (snippet_id, revision, title, tags, content, files, created_at)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?
+		FROM snippet_revisions WHERE snippet_id = ?`,
+		snippetID, s.Title, string(tagsJSON), s.Content, filesJSON, formatTimestamp(at), snippetID)
+	return err
+}
+
+// History returns every revision of a snippet, oldest first
+func (store *SQLiteStore) History(id int) ([]models.Revision, error) {
+	if _, err := store.Get(id); err != nil {
+		return nil, err
+	}
+
+	rows, err := store.conn().Query(`SELECT snippet_id, revision, title, tags, content, files, created_at
+		FROM snippet_revisions WHERE snippet_id = ? ORDER BY revision`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.Revision
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *r)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Revision returns a single revision of a snippet
+func (store *SQLiteStore) Revision(id int, number int) (*models.Revision, error) {
+	row := store.conn().QueryRow(`SELECT snippet_id, revision, title, tags, content, files, created_at
+		FROM snippet_revisions WHERE snippet_id = ? AND revision = ?`, id, number)
+
+	r, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revision %d of snippet %d not found", number, id)
+	}
+	return r, err
+}
+
+// scanRevision reads a snippet_revisions row from rows or a single row
+func scanRevision(row interface{ Scan(dest ...any) error }) (*models.Revision, error) {
+	var r models.Revision
+	var tagsJSON, filesJSON string
+	var createdAtStr string
+
+	if err := row.Scan(&r.SnippetID, &r.Number, &r.Title, &tagsJSON, &r.Content, &filesJSON, &createdAtStr); err != nil {
+		return nil, err
+	}
+
+	var err error
+	r.Tags, err = decodeTags(tagsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Files, err = decodeFiles(filesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	r.CreatedAt, err = parseTimestamp(createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp on revision %d of snippet %d: %w", r.Number, r.SnippetID, err)
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,554 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is the Store implementation backed by a SQLite database file
+type SQLiteStore struct {
+	db *sql.DB
+	// writeMu queues this process's writers. SQLite allows one writer at a
+	// time, and waiting here is fairer than retrying in the busy handler.
+	writeMu *sync.Mutex
+	// tx is set on the store handed to a Transaction callback
+	tx   *sql.Tx
+	path string
+}
+
+// dsnParams are applied to every connection. WAL lets readers carry on while
+// another process writes, busy_timeout makes a writer wait for the lock
+// instead of failing with "database is locked", and immediate transactions
+// take the write lock up front, so two writers never deadlock upgrading from
+// a read lock. Foreign keys are off by default in SQLite and must be enabled
+// per connection.
+const dsnParams = "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate"
+
+// OpenSQLite opens the SQLite database at dbPath, creating it and its parent
+// directory if needed, and applies any pending schema migrations
+func OpenSQLite(dbPath string) (*SQLiteStore, error) {
+	db, err := openSQLiteDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSQLiteStore(db, dbPath)
+}
+
+// OpenSQLiteUnmigrated opens the SQLite database at dbPath like OpenSQLite,
+// but leaves pending migrations for the caller to report or apply with
+// Migrate. Snippets can't be read or written until they are applied.
+func OpenSQLiteUnmigrated(dbPath string) (*SQLiteStore, error) {
+	db, err := openSQLiteDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureSchemaVersionTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db, writeMu: &sync.Mutex{}, path: dbPath}, nil
+}
+
+// openSQLiteDB opens the database file at dbPath, creating its parent
+// directory if needed
+func openSQLiteDB(dbPath string) (*sql.DB, error) {
+	// Ensure directory exists
+	dbDir := filepath.Dir(dbPath)
+	if err := os.MkdirAll(dbDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	return sql.Open("sqlite", dbPath+dsnParams)
+}
+
+// OpenMemory opens a private, empty in-memory SQLite store. It is intended
+// for tests and is discarded when closed.
+func OpenMemory() (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+
+	// Every connection to :memory: gets its own database, so pin the pool to one
+	db.SetMaxOpenConns(1)
+
+	return newSQLiteStore(db, ":memory:")
+}
+
+// newSQLiteStore wraps an open database and brings its schema up to date
+func newSQLiteStore(db *sql.DB, path string) (*SQLiteStore, error) {
+	if _, err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db, writeMu: &sync.Mutex{}, path: path}, nil
+}
+
+// Close releases the underlying database handle. The store given to a
+// Transaction callback doesn't own the handle, so closing it does nothing.
+func (store *SQLiteStore) Close() error {
+	if store.tx != nil {
+		return nil
+	}
+	return store.db.Close()
+}
+
+// dbConn is the part of *sql.DB and *sql.Tx that queries run on
+type dbConn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// conn returns the enclosing transaction, if any, or the database
+func (store *SQLiteStore) conn() dbConn {
+	if store.tx != nil {
+		return store.tx
+	}
+	return store.db
+}
+
+// writeTx is a write transaction, or a savepoint within the enclosing
+// Transaction, so a failed write is undone without abandoning the batch
+type writeTx struct {
+	*sql.Tx
+	savepoint bool
+	done      bool
+	unlock    func()
+}
+
+// begin starts a write transaction
+func (store *SQLiteStore) begin() (*writeTx, error) {
+	if store.tx != nil {
+		if _, err := store.tx.Exec(`SAVEPOINT write`); err != nil {
+			return nil, err
+		}
+		return &writeTx{Tx: store.tx, savepoint: true}, nil
+	}
+
+	store.writeMu.Lock()
+	tx, err := store.db.Begin()
+	if err != nil {
+		store.writeMu.Unlock()
+		return nil, err
+	}
+	return &writeTx{Tx: tx, unlock: store.writeMu.Unlock}, nil
+}
+
+// Commit commits the transaction or releases the savepoint
+func (t *writeTx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	if t.savepoint {
+		_, err := t.Exec(`RELEASE write`)
+		return err
+	}
+	defer t.unlock()
+	return t.Tx.Commit()
+}
+
+// Rollback undoes the transaction or everything since the savepoint. Like
+// sql.Tx.Rollback, it does nothing after Commit.
+func (t *writeTx) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	if !t.savepoint {
+		defer t.unlock()
+		return t.Tx.Rollback()
+	}
+	if _, err := t.Exec(`ROLLBACK TO write`); err != nil {
+		return err
+	}
+	_, err := t.Exec(`RELEASE write`)
+	return err
+}
+
+// exec runs a single write statement
+func (store *SQLiteStore) exec(query string, args ...any) (sql.Result, error) {
+	if store.tx == nil {
+		store.writeMu.Lock()
+		defer store.writeMu.Unlock()
+	}
+	return store.conn().Exec(query, args...)
+}
+
+// Transaction runs fn with a store that reads and writes within a single
+// transaction, committed when fn returns nil and rolled back otherwise.
+// Each write fn makes is undone on its own if it fails, so fn may carry on
+// after an error.
+func (store *SQLiteStore) Transaction(fn func(tx Store) error) error {
+	if store.tx != nil {
+		return fn(store)
+	}
+
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteStore{db: store.db, writeMu: store.writeMu, tx: tx, path: store.path}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Save inserts a new snippet and returns its ID
+func (store *SQLiteStore) Save(s models.Snippet) (int64, error) {
+	tx, err := store.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := assignIdentifiers(tx.Tx, &s); err != nil {
+		return 0, err
+	}
+
+	collection, err := CleanCollection(s.Collection)
+	if err != nil {
+		return 0, err
+	}
+	meta, err := CleanMeta(s.Meta)
+	if err != nil {
+		return 0, err
+	}
+
+	createdAt := formatTimestamp(s.CreatedAt)
+	res, err := tx.Exec(`INSERT INTO snippets (uuid, slug, title, content, content_hash, encrypted, language, description,
+			source_url, collection, pinned, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.UUID, s.Slug, s.Title, s.Content, snippetHash(s), s.Encrypted, s.Language, s.Description, s.SourceURL,
+		collection, s.Pinned, createdAt, createdAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setTags(tx.Tx, id, s.Tags); err != nil {
+		return 0, err
+	}
+	if err := setMeta(tx.Tx, id, meta); err != nil {
+		return 0, err
+	}
+
+	if err := setFiles(tx.Tx, id, s.Files); err != nil {
+		return 0, err
+	}
+
+	if err := recordRevision(tx.Tx, id, s, s.CreatedAt); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// List returns every snippet not in the trash in the requested order
+func (store *SQLiteStore) List(opts ListOptions) ([]models.Snippet, error) {
+	orderBy, err := opts.Sort.orderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"s.deleted_at IS NULL"}
+	condition, params := opts.collectionCondition()
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+	if opts.Pinned {
+		conditions = append(conditions, "s.pinned")
+	}
+	metaConditions, metaParams := opts.metaConditions()
+	conditions = append(conditions, metaConditions...)
+	params = append(params, metaParams...)
+
+	return store.querySnippets(`SELECT `+snippetColumns+` FROM snippets s
+		WHERE `+strings.Join(conditions, " AND ")+` ORDER BY `+pinnedFirst+orderBy, params...)
+}
+
+// Get returns a single snippet by its ID
+func (store *SQLiteStore) Get(id int) (*models.Snippet, error) {
+	row := store.conn().QueryRow(`SELECT `+snippetColumns+` FROM snippets s WHERE s.id = ? AND s.deleted_at IS NULL`, id)
+
+	s, err := scanSnippet(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snippet with ID %d not found", id)
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Search returns snippets matching query in title, tags, or content, best
+// match first. An empty query lists every snippet, newest first, which is
+// useful together with a tag filter.
+func (store *SQLiteStore) Search(query string, tagFilter string) ([]models.Snippet, error) {
+	from := "snippets s"
+	orderBy := "s.created_at DESC"
+	conditions := []string{"s.deleted_at IS NULL"}
+	var params []any
+
+	if query = strings.TrimSpace(query); query != "" {
+		match := ftsQuery(query)
+		if match == "" {
+			return nil, nil
+		}
+		from = "snippets_fts JOIN snippets s ON s.id = snippets_fts.rowid"
+		conditions = append(conditions, "snippets_fts MATCH ?")
+		params = append(params, match)
+		orderBy = ftsRank + ", s.created_at DESC"
+	}
+
+	if tagFilter = strings.TrimSpace(tagFilter); tagFilter != "" {
+		// Tags are matched exactly through the join table
+		conditions = append(conditions, hasTagClause)
+		params = append(params, tagFilter)
+	}
+
+	return store.querySnippets(`
+		SELECT `+snippetColumns+`
+		FROM `+from+`
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY `+pinnedFirst+orderBy, params...)
+}
+
+// Update updates an existing snippet and records the result as a new revision
+func (store *SQLiteStore) Update(s models.Snippet) error {
+	tx, err := store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE snippets SET title = ?, content = ?, content_hash = ?, encrypted = ?, language = ?,
+			description = ?, source_url = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		s.Title, s.Content, snippetHash(s), s.Encrypted, s.Language, s.Description, s.SourceURL, formatTimestamp(now), s.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d not found", s.ID)
+	}
+
+	if err := setTags(tx.Tx, int64(s.ID), s.Tags); err != nil {
+		return err
+	}
+
+	if err := setFiles(tx.Tx, int64(s.ID), s.Files); err != nil {
+		return err
+	}
+
+	if err := recordRevision(tx.Tx, int64(s.ID), s, now); err != nil {
+		return err
+	}
+
+	if err := pruneTags(tx.Tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete moves a snippet to the trash. It stays recoverable with Restore
+// until it is purged.
+func (store *SQLiteStore) Delete(id int) error {
+	result, err := store.exec(`UPDATE snippets SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		formatTimestamp(time.Now()), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// Move files a snippet in another collection. Moving isn't an edit, so no
+// revision is recorded.
+func (store *SQLiteStore) Move(id int, collection string) error {
+	collection, err := CleanCollection(collection)
+	if err != nil {
+		return err
+	}
+
+	result, err := store.exec(`UPDATE snippets SET collection = ? WHERE id = ? AND deleted_at IS NULL`, collection, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// Pin pins or unpins a snippet. Like moving, pinning isn't an edit, so no
+// revision is recorded.
+func (store *SQLiteStore) Pin(id int, pinned bool) error {
+	result, err := store.exec(`UPDATE snippets SET pinned = ? WHERE id = ? AND deleted_at IS NULL`, pinned, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// MarkUsed records that a snippet was just used, bumping its use count
+func (store *SQLiteStore) MarkUsed(id int) error {
+	result, err := store.exec(`UPDATE snippets SET use_count = use_count + 1, last_used_at = ?
+		WHERE id = ? AND deleted_at IS NULL`, formatTimestamp(time.Now()), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// snippetColumns is the column list read by scanSnippet. It expects the
+// snippets table to be aliased as s.
+const snippetColumns = `s.id, COALESCE(s.uuid, ''), COALESCE(s.slug, ''), s.title, ` + tagsColumn + `, s.content, s.created_at,
+	s.updated_at, s.last_used_at, s.use_count, s.deleted_at, s.content_hash, s.encrypted, ` + filesColumn + `,
+	s.language, s.description, s.source_url, s.collection, s.pinned, ` + linksColumn + `, ` + backlinksColumn + `,
+	` + metaColumn
+
+// querySnippets runs a query selecting snippetColumns and scans every row
+func (store *SQLiteStore) querySnippets(query string, args ...any) ([]models.Snippet, error) {
+	rows, err := store.conn().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []models.Snippet
+	for rows.Next() {
+		s, err := scanSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, *s)
+	}
+
+	return snippets, rows.Err()
+}
+
+// scanSnippet reads one row selected with snippetColumns
+func scanSnippet(row interface{ Scan(dest ...any) error }) (*models.Snippet, error) {
+	var s models.Snippet
+	var tagsJSON, filesJSON, linksJSON, backlinksJSON, metaJSON string
+	var createdAt, updatedAt, lastUsedAt, deletedAt sql.NullString
+
+	err := row.Scan(&s.ID, &s.UUID, &s.Slug, &s.Title, &tagsJSON, &s.Content, &createdAt,
+		&updatedAt, &lastUsedAt, &s.UseCount, &deletedAt, &s.ContentHash, &s.Encrypted, &filesJSON,
+		&s.Language, &s.Description, &s.SourceURL, &s.Collection, &s.Pinned, &linksJSON, &backlinksJSON, &metaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse tags
+	s.Tags, err = decodeTags(tagsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Files, err = decodeFiles(filesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Links, err = decodeLinks(linksJSON); err != nil {
+		return nil, err
+	}
+	if s.Backlinks, err = decodeLinks(backlinksJSON); err != nil {
+		return nil, err
+	}
+	if s.Meta, err = decodeMeta(metaJSON); err != nil {
+		return nil, err
+	}
+
+	// Parse timestamps. A bad value is reported rather than replaced, so a
+	// corrupt row never masquerades as a brand new snippet.
+	for _, field := range []struct {
+		name  string
+		value sql.NullString
+		dest  *time.Time
+	}{
+		{"created_at", createdAt, &s.CreatedAt},
+		{"updated_at", updatedAt, &s.UpdatedAt},
+		{"last_used_at", lastUsedAt, &s.LastUsedAt},
+		{"deleted_at", deletedAt, &s.DeletedAt},
+	} {
+		if !field.value.Valid || field.value.String == "" {
+			continue
+		}
+		*field.dest, err = parseTimestamp(field.value.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s on snippet %d: %w", field.name, s.ID, err)
+		}
+	}
+
+	return &s, nil
+}
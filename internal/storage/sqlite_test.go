@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// Each stress worker saves stressSnippets snippets, then updates and uses
+// every one of them stressEdits times
+const (
+	stressWorkers  = 8
+	stressSnippets = 5
+	stressEdits    = 4
+)
+
+// hammer runs one stress worker's writes against store
+func hammer(store Store, worker int) error {
+	var ids []int
+	for i := 0; i < stressSnippets; i++ {
+		id, err := store.Save(models.Snippet{
+			Title:     fmt.Sprintf("worker %d snippet %d", worker, i),
+			Tags:      []string{"stress", fmt.Sprintf("worker-%d", worker)},
+			Content:   fmt.Sprintf("echo %d %d", worker, i),
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("worker %d: save: %w", worker, err)
+		}
+		ids = append(ids, int(id))
+	}
+
+	for edit := 1; edit <= stressEdits; edit++ {
+		for _, id := range ids {
+			s, err := store.Get(id)
+			if err != nil {
+				return fmt.Errorf("worker %d: get %d: %w", worker, id, err)
+			}
+			s.Content = fmt.Sprintf("%s # edit %d", s.Content, edit)
+			if err := store.Update(*s); err != nil {
+				return fmt.Errorf("worker %d: update %d: %w", worker, id, err)
+			}
+			if err := store.MarkUsed(id); err != nil {
+				return fmt.Errorf("worker %d: mark %d used: %w", worker, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkStressed verifies that every write of workers stress workers landed
+func checkStressed(t *testing.T, store Store, workers int) {
+	t.Helper()
+
+	snippets, err := store.List(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets) != workers*stressSnippets {
+		t.Fatalf("got %d snippets, want %d", len(snippets), workers*stressSnippets)
+	}
+
+	for _, s := range snippets {
+		revisions, err := store.History(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 1+stressEdits {
+			t.Errorf("'%s' has %d revisions, want %d", s.Title, len(revisions), 1+stressEdits)
+		}
+		for i, r := range revisions {
+			if r.Number != i+1 {
+				t.Errorf("'%s' revision %d is numbered %d", s.Title, i+1, r.Number)
+			}
+		}
+		if s.UseCount != stressEdits {
+			t.Errorf("'%s' was used %d times, want %d", s.Title, s.UseCount, stressEdits)
+		}
+		if strings.Count(s.Content, "# edit") != stressEdits {
+			t.Errorf("'%s' has content %q, want %d edits", s.Title, s.Content, stressEdits)
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "snippets.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, stressWorkers)
+	for worker := 0; worker < stressWorkers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- hammer(store, worker)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	checkStressed(t, store, stressWorkers)
+}
+
+// TestConcurrentProcesses runs stress workers in separate processes, each
+// a copy of the test binary with its own handle on the same database. The
+// workers open the database at the same time, so they race on migrations
+// too.
+func TestConcurrentProcesses(t *testing.T) {
+	if path := os.Getenv("SNIP_STRESS_DB"); path != "" {
+		worker, err := strconv.Atoi(os.Getenv("SNIP_STRESS_WORKER"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatalf("worker %d: open: %v", worker, err)
+		}
+		defer store.Close()
+		if err := hammer(store, worker); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "snippets.db")
+	var wg sync.WaitGroup
+	for worker := 0; worker < stressWorkers; worker++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentProcesses$")
+		cmd.Env = append(os.Environ(), "SNIP_STRESS_DB="+path, fmt.Sprintf("SNIP_STRESS_WORKER=%d", worker))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("worker %d: %v\n%s", worker, err, out)
+			}
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	checkStressed(t, store, stressWorkers)
+}
@@ -1,236 +1,132 @@
 package storage
 
 import (
-	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/lubasinkal/snip/internal/models"
-	_ "modernc.org/sqlite"
 )
 
-var db *sql.DB
-
-func init() {
-	var err error
-
-	// Get database path
-	dbPath := getDBPath()
-
-	// Ensure directory exists
-	dbDir := filepath.Dir(dbPath)
-	err = os.MkdirAll(dbDir, 0755)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to create database directory: %v", err))
-	}
-
-	db, err = sql.Open("sqlite", dbPath)
-	if err != nil {
-		panic(err)
-	}
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS snippets (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        title TEXT,
-        tags TEXT,
-        content TEXT,
-        created_at DATETIME
-    )`)
-	if err != nil {
-		panic(err)
-	}
+// Store is the persistence layer used by every snip command
+type Store interface {
+	// Save inserts a new snippet and returns its ID
+	Save(s models.Snippet) (int64, error)
+	// Get returns a single snippet by its ID
+	Get(id int) (*models.Snippet, error)
+	// Lookup returns a single snippet by its ID, UUID or slug, or ErrNotFound
+	Lookup(ref string) (*models.Snippet, error)
+	// FindByTitle returns snippets with the given title, or failing that,
+	// those whose title or slug starts with it
+	FindByTitle(title string) ([]models.Snippet, error)
+	// List returns every snippet not in the trash in the requested order
+	List(opts ListOptions) ([]models.Snippet, error)
+	// Search returns snippets matching query, optionally limited to a tag
+	Search(query string, tagFilter string) ([]models.Snippet, error)
+	// FindDuplicates returns snippets whose normalized content matches that of s
+	FindDuplicates(s models.Snippet) ([]models.Snippet, error)
+	// Update saves changes to an existing snippet
+	Update(s models.Snippet) error
+	// MarkUsed records that a snippet was just used
+	MarkUsed(id int) error
+	// Move files a snippet in another collection; "" unfiles it
+	Move(id int, collection string) error
+	// Pin pins or unpins a snippet; pinned snippets are listed first
+	Pin(id int, pinned bool) error
+	// Link records that snippet from relates to snippet to, replacing any
+	// earlier link between them in that direction
+	Link(from, to int, rel string) error
+	// Unlink removes the link from snippet from to snippet to, if any
+	Unlink(from, to int) error
+	// SetMeta sets one custom metadata field of a snippet
+	SetMeta(id int, key string, value string) error
+	// UnsetMeta removes one custom metadata field of a snippet, if set
+	UnsetMeta(id int, key string) error
+	// Delete moves a snippet to the trash
+	Delete(id int) error
+	// Trash returns every trashed snippet, most recently deleted first
+	Trash() ([]models.Snippet, error)
+	// Restore moves a snippet out of the trash
+	Restore(id int) error
+	// Purge permanently removes snippets trashed at or before the given time
+	Purge(before time.Time) (int64, error)
+	// History returns every saved revision of a snippet, oldest first
+	History(id int) ([]models.Revision, error)
+	// Revision returns a single revision of a snippet
+	Revision(id int, number int) (*models.Revision, error)
+	// Close releases any resources held by the store
+	Close() error
 }
 
-func SaveSnippet(s models.Snippet) (int64, error) {
-	res, err := db.Exec(`INSERT INTO snippets (title, tags, content, created_at) VALUES (?, ?, ?, ?)`,
-		s.Title, strings.Join(s.Tags, ","), s.Content, s.CreatedAt.Format("2006-01-02 15:04:05"))
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+// Migrator is implemented by stores with a versioned schema
+type Migrator interface {
+	Migrate() ([]MigrationInfo, error)
+	MigrationStatus() ([]MigrationInfo, error)
+	SchemaVersion() (int, error)
 }
 
-// ListAllSnippets returns all snippets from the database
-func ListAllSnippets() ([]models.Snippet, error) {
-	rows, err := db.Query(`SELECT id, title, tags, content, created_at FROM snippets ORDER BY created_at DESC`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var snippets []models.Snippet
-	for rows.Next() {
-		var s models.Snippet
-		var tagsStr string
-		var createdAtStr string
-
-		err := rows.Scan(&s.ID, &s.Title, &tagsStr, &s.Content, &createdAtStr)
-		if err != nil {
-			return nil, err
-		}
-
-		// Parse tags
-		if tagsStr != "" {
-			s.Tags = strings.Split(tagsStr, ",")
-		}
-
-		// Parse created_at
-		if createdAtStr != "" {
-			if parsedTime, err := time.Parse("2006-01-02 15:04:05", createdAtStr); err == nil {
-				s.CreatedAt = parsedTime
-			} else {
-				// Try alternative format
-				if parsedTime, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-					s.CreatedAt = parsedTime
-				} else {
-					s.CreatedAt = time.Now() // fallback
-				}
-			}
-		}
-
-		snippets = append(snippets, s)
-	}
-
-	return snippets, nil
+// Transactor is implemented by stores that can group several operations
+// into one atomic transaction
+type Transactor interface {
+	Transaction(fn func(tx Store) error) error
 }
 
-// GetSnippetByID returns a single snippet by its ID
-func GetSnippetByID(id int) (*models.Snippet, error) {
-	var s models.Snippet
-	var tagsStr string
-	var createdAtStr string
-
-	err := db.QueryRow(`SELECT id, title, tags, content, created_at FROM snippets WHERE id = ?`, id).
-		Scan(&s.ID, &s.Title, &tagsStr, &s.Content, &createdAtStr)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("snippet with ID %d not found", id)
-		}
-		return nil, err
-	}
-
-	// Parse tags
-	if tagsStr != "" {
-		s.Tags = strings.Split(tagsStr, ",")
-	}
-
-	// Parse created_at
-	if createdAtStr != "" {
-		if parsedTime, err := time.Parse("2006-01-02 15:04:05", createdAtStr); err == nil {
-			s.CreatedAt = parsedTime
-		} else {
-			// Try alternative format
-			if parsedTime, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-				s.CreatedAt = parsedTime
-			} else {
-				s.CreatedAt = time.Now() // fallback
-			}
-		}
-	}
-
-	return &s, nil
+// Maintainer is implemented by stores kept in a single database file
+type Maintainer interface {
+	// Path returns the location of the database file
+	Path() string
+	// Info returns the size, schema version and row counts of the database
+	Info() (*DBInfo, error)
+	// Check looks for corruption and inconsistent derived data
+	Check() (*CheckResult, error)
+	// Repair rebuilds derived data such as the search index
+	Repair() error
+	// Vacuum compacts the database file
+	Vacuum() error
+	// Backup writes a consistent copy of the database to path
+	Backup(path string) error
+	// RestoreFrom replaces the database with the backup at path
+	RestoreFrom(path string) error
 }
 
-// SearchSnippets searches for snippets by query in title, tags, or content
-func SearchSnippets(query string, tagFilter string) ([]models.Snippet, error) {
-	var rows *sql.Rows
-	var err error
-
-	query = strings.ToLower(query)
-
-	if tagFilter != "" {
-		// Search with tag filter - use word boundaries to match exact tags
-		rows, err = db.Query(`
-			SELECT id, title, tags, content, created_at
-			FROM snippets
-			WHERE (LOWER(title) LIKE ? OR LOWER(content) LIKE ?)
-			AND (LOWER(tags) LIKE ? OR LOWER(tags) LIKE ? OR LOWER(tags) LIKE ? OR LOWER(tags) = ?)
-			ORDER BY created_at DESC`,
-			"%"+query+"%", "%"+query+"%",
-			strings.ToLower(tagFilter)+",%", "%,"+strings.ToLower(tagFilter)+",%", "%,"+strings.ToLower(tagFilter), strings.ToLower(tagFilter))
-	} else {
-		// Search without tag filter
-		rows, err = db.Query(`
-			SELECT id, title, tags, content, created_at
-			FROM snippets
-			WHERE LOWER(title) LIKE ? OR LOWER(tags) LIKE ? OR LOWER(content) LIKE ?
-			ORDER BY created_at DESC`,
-			"%"+query+"%", "%"+query+"%", "%"+query+"%")
-	}
-
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var snippets []models.Snippet
-	for rows.Next() {
-		var s models.Snippet
-		var tagsStr string
-		var createdAtStr string
-
-		err := rows.Scan(&s.ID, &s.Title, &tagsStr, &s.Content, &createdAtStr)
-		if err != nil {
-			return nil, err
-		}
-
-		// Parse tags
-		if tagsStr != "" {
-			s.Tags = strings.Split(tagsStr, ",")
-		}
-
-		// Parse created_at
-		if createdAtStr != "" {
-			if parsedTime, err := time.Parse("2006-01-02 15:04:05", createdAtStr); err == nil {
-				s.CreatedAt = parsedTime
-			} else {
-				// Try alternative format
-				if parsedTime, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-					s.CreatedAt = parsedTime
-				} else {
-					s.CreatedAt = time.Now() // fallback
-				}
-			}
-		}
-
-		snippets = append(snippets, s)
-	}
-
-	return snippets, nil
-}
+// Backends a library can be stored with
+const (
+	// BackendSQLite keeps a library in a single SQLite database file
+	BackendSQLite = "sqlite"
+	// BackendFiles keeps a library as a directory of plain snippet files
+	BackendFiles = "files"
+)
 
-// UpdateSnippet updates an existing snippet
-func UpdateSnippet(s models.Snippet) error {
-	_, err := db.Exec(`UPDATE snippets SET title = ?, tags = ?, content = ? WHERE id = ?`,
-		s.Title, strings.Join(s.Tags, ","), s.Content, s.ID)
-	return err
+// Open opens the default store at DBPath
+func Open() (Store, error) {
+	return OpenSQLite(DBPath())
 }
 
-// DeleteSnippet removes a snippet by ID
-func DeleteSnippet(id int) error {
-	result, err := db.Exec(`DELETE FROM snippets WHERE id = ?`, id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+// OpenBackend opens the store at path with the given backend. An empty
+// backend means BackendSQLite.
+func OpenBackend(backend string, path string) (Store, error) {
+	switch backend {
+	case "", BackendSQLite:
+		return OpenSQLite(path)
+	case BackendFiles:
+		return OpenFiles(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend '%s'. Use '%s' or '%s'", backend, BackendSQLite, BackendFiles)
 	}
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("snippet with ID %d not found", id)
+// OpenBackendUnmigrated opens the store at path like OpenBackend, leaving
+// any pending schema migrations unapplied
+func OpenBackendUnmigrated(backend string, path string) (Store, error) {
+	if backend == "" || backend == BackendSQLite {
+		return OpenSQLiteUnmigrated(path)
 	}
-
-	return nil
+	return OpenBackend(backend, path)
 }
 
-// getDBPath returns the path to the database file
-func getDBPath() string {
+// DBPath returns the path to the database file
+func DBPath() string {
 	// Check for custom path in environment variable
 	if dbPath := os.Getenv("SNIP_DB_PATH"); dbPath != "" {
 		return dbPath
@@ -0,0 +1,369 @@
+package storage
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+	"github.com/lubasinkal/snip/internal/secret"
+)
+
+// backends opens an empty store of every kind, so each conformance case
+// checks that they behave the same
+var backends = []struct {
+	name string
+	open func(t *testing.T) Store
+}{
+	{"sqlite", func(t *testing.T) Store {
+		store, err := OpenMemory()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}},
+	{"files", func(t *testing.T) Store {
+		store, err := OpenFiles(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}},
+}
+
+// seed saves the snippets every conformance case starts from, oldest first,
+// and returns their IDs by title
+func seed(t *testing.T, store Store) map[string]int {
+	t.Helper()
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	ids := make(map[string]int)
+	for i, s := range []models.Snippet{
+		{Title: "Docker prune", Tags: []string{"docker", "cleanup"}, Content: "docker system prune -f", Collection: "infra"},
+		{Title: "Docker build", Tags: []string{"docker"}, Content: "docker build -t app .", Collection: "infra/ci"},
+		{Title: "Go hello world", Tags: []string{"go"}, Content: `fmt.Println("hello")`, Language: "go"},
+		{Title: "Kubectl pods", Tags: []string{"k8s"}, Content: "kubectl get pods -A", Collection: "infra/k8s"},
+	} {
+		s.CreatedAt = created.Add(time.Duration(i) * time.Hour)
+		id, err := store.Save(s)
+		if err != nil {
+			t.Fatalf("save '%s': %v", s.Title, err)
+		}
+		ids[s.Title] = int(id)
+	}
+	return ids
+}
+
+// titles returns the titles of snippets, in order
+func titles(snippets []models.Snippet) []string {
+	var titles []string
+	for _, s := range snippets {
+		titles = append(titles, s.Title)
+	}
+	return titles
+}
+
+// wantTitles fails the test unless snippets have exactly the given titles,
+// in order
+func wantTitles(t *testing.T, what string, snippets []models.Snippet, err error, want ...string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if got := titles(snippets); !slices.Equal(got, want) {
+		t.Errorf("%s = %q, want %q", what, got, want)
+	}
+}
+
+// get returns a snippet that must exist
+func get(t *testing.T, store Store, id int) *models.Snippet {
+	t.Helper()
+	s, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("get %d: %v", id, err)
+	}
+	return s
+}
+
+var conformance = []struct {
+	name string
+	run  func(t *testing.T, store Store, ids map[string]int)
+}{
+	{"save and look up", func(t *testing.T, store Store, ids map[string]int) {
+		s := get(t, store, ids["Docker prune"])
+		if s.Slug != "docker-prune" || s.Content != "docker system prune -f" || s.Collection != "infra" ||
+			!slices.Equal(s.Tags, []string{"docker", "cleanup"}) || s.UUID == "" {
+			t.Errorf("saved snippet = %+v", s)
+		}
+		if s.UpdatedAt != s.CreatedAt || s.UseCount != 0 || !s.LastUsedAt.IsZero() {
+			t.Errorf("new snippet has times %v, %v, %v and %d uses", s.CreatedAt, s.UpdatedAt, s.LastUsedAt, s.UseCount)
+		}
+
+		for _, ref := range []string{strconv.Itoa(s.ID), "docker-prune", s.UUID} {
+			found, err := store.Lookup(ref)
+			if err != nil || found.ID != s.ID {
+				t.Errorf("lookup %s = %v, %v", ref, found, err)
+			}
+		}
+		if _, err := store.Lookup("docker"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("lookup docker: %v, want ErrNotFound", err)
+		}
+	}},
+	{"find by title", func(t *testing.T, store Store, ids map[string]int) {
+		found, err := store.FindByTitle("docker PRUNE")
+		wantTitles(t, "exact title", found, err, "Docker prune")
+		found, err = store.FindByTitle("dock")
+		wantTitles(t, "title prefix", found, err, "Docker prune", "Docker build")
+		found, err = store.FindByTitle("kubectl-p")
+		wantTitles(t, "slug prefix", found, err, "Kubectl pods")
+		found, err = store.FindByTitle("nothing")
+		wantTitles(t, "no match", found, err)
+	}},
+	{"list", func(t *testing.T, store Store, ids map[string]int) {
+		list, err := store.List(ListOptions{})
+		wantTitles(t, "newest first", list, err, "Kubectl pods", "Go hello world", "Docker build", "Docker prune")
+		list, err = store.List(ListOptions{Sort: SortTitle})
+		wantTitles(t, "by title", list, err, "Docker build", "Docker prune", "Go hello world", "Kubectl pods")
+		list, err = store.List(ListOptions{Collection: "infra"})
+		wantTitles(t, "collection", list, err, "Docker prune")
+		list, err = store.List(ListOptions{Collection: "infra", Recursive: true})
+		wantTitles(t, "nested collections", list, err, "Kubectl pods", "Docker build", "Docker prune")
+
+		if err := store.Pin(ids["Docker prune"], true); err != nil {
+			t.Fatal(err)
+		}
+		list, err = store.List(ListOptions{})
+		wantTitles(t, "pinned first", list, err, "Docker prune", "Kubectl pods", "Go hello world", "Docker build")
+		list, err = store.List(ListOptions{Pinned: true})
+		wantTitles(t, "pinned only", list, err, "Docker prune")
+	}},
+	{"search", func(t *testing.T, store Store, ids map[string]int) {
+		found, err := store.Search("prune", "")
+		wantTitles(t, "word", found, err, "Docker prune")
+		found, err = store.Search("dock", "")
+		wantTitles(t, "prefix", found, err, "Docker build", "Docker prune")
+		found, err = store.Search("docker", "cleanup")
+		wantTitles(t, "tag filter", found, err, "Docker prune")
+		found, err = store.Search("k8s", "")
+		wantTitles(t, "tag", found, err, "Kubectl pods")
+		found, err = store.Search(`"get pods"`, "")
+		wantTitles(t, "phrase", found, err, "Kubectl pods")
+		found, err = store.Search("golang", "")
+		wantTitles(t, "no match", found, err)
+	}},
+	{"encrypted content is not searchable", func(t *testing.T, store Store, ids map[string]int) {
+		found, err := store.Search("Println", "")
+		wantTitles(t, "before encrypting", found, err, "Go hello world")
+
+		s := get(t, store, ids["Go hello world"])
+		sealed, err := secret.Encrypt(s.Content, "pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Content, s.Encrypted = sealed, true
+		if err := store.Update(*s); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Save(models.Snippet{Title: "Deploy token", Content: sealed, Encrypted: true, CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err = store.Search("Println", "")
+		wantTitles(t, "old plaintext", found, err)
+		found, err = store.Search("encrypted", "")
+		wantTitles(t, "ciphertext", found, err)
+		found, err = store.Search("hello", "")
+		wantTitles(t, "title", found, err, "Go hello world")
+
+		if sqlite, ok := store.(*SQLiteStore); ok {
+			var indexed int
+			err := sqlite.db.QueryRow(`SELECT COUNT(*) FROM snippets_fts
+				WHERE rowid IN (SELECT id FROM snippets WHERE encrypted) AND content != ''`).Scan(&indexed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if indexed != 0 {
+				t.Errorf("%d encrypted snippets have content in the search index", indexed)
+			}
+		}
+	}},
+	{"update and history", func(t *testing.T, store Store, ids map[string]int) {
+		s := get(t, store, ids["Go hello world"])
+		s.Title = "Go greeting"
+		s.Tags = []string{"go", "fmt"}
+		s.Content = `fmt.Println("hi")`
+		if err := store.Update(*s); err != nil {
+			t.Fatal(err)
+		}
+
+		updated := get(t, store, s.ID)
+		if updated.Title != "Go greeting" || updated.Content != `fmt.Println("hi")` || !slices.Equal(updated.Tags, []string{"go", "fmt"}) {
+			t.Errorf("updated snippet = %+v", updated)
+		}
+		if updated.Slug != "go-hello-world" || !updated.UpdatedAt.After(updated.CreatedAt) {
+			t.Errorf("updated snippet has slug %s and was updated at %v", updated.Slug, updated.UpdatedAt)
+		}
+
+		history, err := store.History(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 || history[0].Number != 1 || history[1].Number != 2 {
+			t.Fatalf("history = %+v", history)
+		}
+		first, err := store.Revision(s.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.Title != "Go hello world" || first.Content != `fmt.Println("hello")` || !slices.Equal(first.Tags, []string{"go"}) {
+			t.Errorf("revision 1 = %+v", first)
+		}
+		if _, err := store.Revision(s.ID, 3); err == nil {
+			t.Error("revision 3 exists")
+		}
+	}},
+	{"links", func(t *testing.T, store Store, ids map[string]int) {
+		from, to := ids["Docker build"], ids["Docker prune"]
+		if err := store.Link(from, to, "depends-on"); err != nil {
+			t.Fatal(err)
+		}
+		if links := get(t, store, from).Links; len(links) != 1 || links[0].ID != to || links[0].Rel != "depends-on" {
+			t.Errorf("links = %+v", links)
+		}
+		if backlinks := get(t, store, to).Backlinks; len(backlinks) != 1 || backlinks[0].ID != from || backlinks[0].Rel != "depends-on" {
+			t.Errorf("backlinks = %+v", backlinks)
+		}
+
+		// Links to trashed snippets are hidden until they are restored
+		if err := store.Delete(to); err != nil {
+			t.Fatal(err)
+		}
+		if links := get(t, store, from).Links; len(links) != 0 {
+			t.Errorf("links to the trash = %+v", links)
+		}
+		if err := store.Restore(to); err != nil {
+			t.Fatal(err)
+		}
+		if links := get(t, store, from).Links; len(links) != 1 {
+			t.Errorf("links after restore = %+v", links)
+		}
+
+		if err := store.Unlink(from, to); err != nil {
+			t.Fatal(err)
+		}
+		if s := get(t, store, to); len(s.Backlinks) != 0 {
+			t.Errorf("backlinks after unlink = %+v", s.Backlinks)
+		}
+	}},
+	{"metadata", func(t *testing.T, store Store, ids map[string]int) {
+		id := ids["Kubectl pods"]
+		for key, value := range map[string]string{"env": "prod", "Owner": " platform "} {
+			if err := store.SetMeta(id, key, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if meta := get(t, store, id).Meta; !maps.Equal(meta, map[string]string{"env": "prod", "owner": "platform"}) {
+			t.Errorf("meta = %v", meta)
+		}
+		list, err := store.List(ListOptions{Meta: map[string]string{"env": "prod"}})
+		wantTitles(t, "meta filter", list, err, "Kubectl pods")
+
+		if err := store.UnsetMeta(id, "env"); err != nil {
+			t.Fatal(err)
+		}
+		if meta := get(t, store, id).Meta; !maps.Equal(meta, map[string]string{"owner": "platform"}) {
+			t.Errorf("meta after unset = %v", meta)
+		}
+		if err := store.SetMeta(id, "bad key", "x"); err == nil {
+			t.Error("set an invalid key")
+		}
+	}},
+	{"trash", func(t *testing.T, store Store, ids map[string]int) {
+		for _, title := range []string{"Docker build", "Go hello world"} {
+			if err := store.Delete(ids[title]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		list, err := store.List(ListOptions{})
+		wantTitles(t, "list after delete", list, err, "Kubectl pods", "Docker prune")
+		found, err := store.Search("docker", "")
+		wantTitles(t, "search after delete", found, err, "Docker prune")
+		trash, err := store.Trash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash) != 2 || trash[0].DeletedAt.IsZero() {
+			t.Errorf("trash = %q", titles(trash))
+		}
+		if _, err := store.Get(ids["Docker build"]); err == nil {
+			t.Error("got a trashed snippet")
+		}
+		if err := store.Delete(ids["Docker build"]); err == nil {
+			t.Error("deleted a trashed snippet")
+		}
+
+		if err := store.Restore(ids["Docker build"]); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Restore(ids["Docker build"]); err == nil {
+			t.Error("restored a snippet that isn't in the trash")
+		}
+		list, err = store.List(ListOptions{})
+		wantTitles(t, "list after restore", list, err, "Kubectl pods", "Docker build", "Docker prune")
+
+		purged, err := store.Purge(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		trash, err = store.Trash()
+		if purged != 1 || err != nil || len(trash) != 0 {
+			t.Errorf("purged %d, leaving %q (%v)", purged, titles(trash), err)
+		}
+		if _, err := store.History(ids["Go hello world"]); err == nil {
+			t.Error("history of a purged snippet")
+		}
+	}},
+	{"duplicates", func(t *testing.T, store Store, ids map[string]int) {
+		found, err := store.FindDuplicates(models.Snippet{Content: "\ndocker system prune -f  \r\n"})
+		wantTitles(t, "same content", found, err, "Docker prune")
+		found, err = store.FindDuplicates(models.Snippet{Content: "docker system prune"})
+		wantTitles(t, "other content", found, err)
+
+		if err := store.Delete(ids["Docker prune"]); err != nil {
+			t.Fatal(err)
+		}
+		found, err = store.FindDuplicates(models.Snippet{Content: "docker system prune -f"})
+		wantTitles(t, "trashed content", found, err)
+	}},
+	{"usage", func(t *testing.T, store Store, ids map[string]int) {
+		for _, title := range []string{"Docker build", "Go hello world", "Docker build"} {
+			if err := store.MarkUsed(ids[title]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if s := get(t, store, ids["Docker build"]); s.UseCount != 2 || s.LastUsedAt.IsZero() {
+			t.Errorf("used %d times, last at %v", s.UseCount, s.LastUsedAt)
+		}
+		list, err := store.List(ListOptions{Sort: SortUseCount})
+		wantTitles(t, "by uses", list, err, "Docker build", "Go hello world", "Kubectl pods", "Docker prune")
+		if err := store.MarkUsed(999); err == nil {
+			t.Error("used a missing snippet")
+		}
+	}},
+}
+
+// TestConformance runs every conformance case against every backend
+func TestConformance(t *testing.T) {
+	for _, backend := range backends {
+		for _, c := range conformance {
+			t.Run(backend.name+"/"+c.name, func(t *testing.T) {
+				store := backend.open(t)
+				defer store.Close()
+				c.run(t, store, seed(t, store))
+			})
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// tagsColumn selects a snippet's tags, in their saved order, as a JSON array.
+// It expects the snippets table to be aliased as s.
+const tagsColumn = `(SELECT json_group_array(t.name ORDER BY st.position)
+	FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+	WHERE st.snippet_id = s.id)`
+
+// hasTagClause matches snippets carrying the bound tag exactly (case-insensitive)
+const hasTagClause = `EXISTS (SELECT 1 FROM snippet_tags st JOIN tags t ON t.id = st.tag_id
+	WHERE st.snippet_id = s.id AND t.name = ?)`
+
+// CleanTags trims whitespace and drops empty and duplicate tags, keeping the
+// first spelling of each tag
+func CleanTags(tags []string) []string {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, tag)
+	}
+	return cleaned
+}
+
+// decodeTags parses the JSON array produced by tagsColumn
+func decodeTags(tagsJSON string) ([]string, error) {
+	var tags []string
+	if tagsJSON == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
+
+// setTags replaces the tags attached to a snippet
+func setTags(tx *sql.Tx, snippetID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM snippet_tags WHERE snippet_id = ?`, snippetID); err != nil {
+		return err
+	}
+
+	for i, tag := range CleanTags(tags) {
+		if _, err := tx.Exec(`INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, tag); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO snippet_tags (snippet_id, tag_id, position)
+			SELECT ?, id, ? FROM tags WHERE name = ?`, snippetID, i, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneTags removes tags that are no longer attached to any snippet
+func pruneTags(tx *sql.Tx) error {
+	_, err := tx.Exec(`DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM snippet_tags)`)
+	return err
+}
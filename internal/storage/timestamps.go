@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// legacyTimestampLayout is how timestamps were written, in local time, before
+// they were normalized to UTC RFC3339
+const legacyTimestampLayout = "2006-01-02 15:04:05"
+
+// formatTimestamp renders t the way every timestamp is stored: RFC3339 in
+// UTC, which also sorts correctly as text
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTimestamp parses a stored timestamp
+func parseTimestamp(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+// normalizeTimestamps rewrites every timestamp stored in the legacy local-time
+// layout as UTC RFC3339. Values that don't parse in either format are left
+// alone so that reading them reports the problem instead of hiding it.
+func normalizeTimestamps(tx *sql.Tx) error {
+	// The layout formatTimestamp used when this migration was released,
+	// kept here so the migration can't follow later changes to it
+	const normalizedLayout = time.RFC3339
+
+	columns := []struct{ table, column string }{
+		{"snippets", "created_at"},
+		{"snippets", "updated_at"},
+		{"snippets", "last_used_at"},
+		{"snippets", "deleted_at"},
+		{"snippet_revisions", "created_at"},
+	}
+
+	for _, c := range columns {
+		// CAST keeps the driver from converting DATETIME columns on the way out
+		rows, err := tx.Query(fmt.Sprintf(`SELECT rowid, CAST(%s AS TEXT) FROM %s WHERE %s IS NOT NULL AND %s != ''`,
+			c.column, c.table, c.column, c.column))
+		if err != nil {
+			return err
+		}
+
+		updates := make(map[int64]string)
+		for rows.Next() {
+			var rowID int64
+			var value string
+			if err := rows.Scan(&rowID, &value); err != nil {
+				rows.Close()
+				return err
+			}
+
+			if t, err := time.ParseInLocation(legacyTimestampLayout, value, time.Local); err == nil {
+				updates[rowID] = t.UTC().Format(normalizedLayout)
+			} else if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				updates[rowID] = t.UTC().Format(normalizedLayout)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for rowID, value := range updates {
+			_, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s = ? WHERE rowid = ?`, c.table, c.column), value, rowID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
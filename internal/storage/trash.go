@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lubasinkal/snip/internal/models"
+)
+
+// Trash returns every soft-deleted snippet, most recently deleted first
+func (store *SQLiteStore) Trash() ([]models.Snippet, error) {
+	return store.querySnippets(`SELECT ` + snippetColumns + ` FROM snippets s
+		WHERE s.deleted_at IS NOT NULL ORDER BY s.deleted_at DESC`)
+}
+
+// Restore moves a snippet out of the trash
+func (store *SQLiteStore) Restore(id int) error {
+	result, err := store.exec(`UPDATE snippets SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snippet with ID %d is not in the trash", id)
+	}
+
+	return nil
+}
+
+// Purge permanently removes trashed snippets deleted at or before the given time,
+// along with their revisions, and returns how many were removed
+func (store *SQLiteStore) Purge(before time.Time) (int64, error) {
+	tx, err := store.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM snippets WHERE deleted_at IS NOT NULL AND deleted_at <= ?`,
+		formatTimestamp(before))
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := pruneTags(tx.Tx); err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
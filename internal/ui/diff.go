@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lubasinkal/snip/internal/diff"
+)
+
+// Diff styles
+var (
+	diffHeaderStyle = lipgloss.NewStyle().
+			Foreground(Text).
+			Bold(true)
+
+	diffHunkStyle = lipgloss.NewStyle().
+			Foreground(Secondary)
+
+	diffInsertStyle = lipgloss.NewStyle().
+			Foreground(Success)
+
+	diffDeleteStyle = lipgloss.NewStyle().
+			Foreground(Error)
+
+	diffContextStyle = lipgloss.NewStyle().
+				Foreground(TextMuted)
+)
+
+// RenderDiff renders hunks as a coloured unified diff between two labelled versions
+func RenderDiff(fromLabel, toLabel string, hunks []diff.Hunk) string {
+	var content strings.Builder
+
+	content.WriteString(diffHeaderStyle.Render("--- " + fromLabel))
+	content.WriteString("\n")
+	content.WriteString(diffHeaderStyle.Render("+++ " + toLabel))
+	content.WriteString("\n")
+
+	for _, hunk := range hunks {
+		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		content.WriteString(diffHunkStyle.Render(header))
+		content.WriteString("\n")
+
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case diff.Insert:
+				content.WriteString(diffInsertStyle.Render("+" + line.Text))
+			case diff.Delete:
+				content.WriteString(diffDeleteStyle.Render("-" + line.Text))
+			default:
+				content.WriteString(diffContextStyle.Render(" " + line.Text))
+			}
+			content.WriteString("\n")
+		}
+	}
+
+	return content.String()
+}
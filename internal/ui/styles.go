@@ -12,12 +12,12 @@ var (
 	Success   = lipgloss.Color("#10B981") // Green
 	Warning   = lipgloss.Color("#F59E0B") // Yellow
 	Error     = lipgloss.Color("#EF4444") // Red
-	
+
 	// Neutral colors
 	Text      = lipgloss.AdaptiveColor{Light: "#1F2937", Dark: "#F9FAFB"}
 	TextMuted = lipgloss.AdaptiveColor{Light: "#6B7280", Dark: "#9CA3AF"}
 	Border    = lipgloss.AdaptiveColor{Light: "#E5E7EB", Dark: "#374151"}
-	
+
 	// Background colors
 	Background = lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#111827"}
 	Surface    = lipgloss.AdaptiveColor{Light: "#F9FAFB", Dark: "#1F2937"}
@@ -27,99 +27,103 @@ var (
 var (
 	// Text styles
 	TitleStyle = lipgloss.NewStyle().
-		Foreground(Primary).
-		Bold(true).
-		MarginBottom(1)
-	
+			Foreground(Primary).
+			Bold(true).
+			MarginBottom(1)
+
 	SubtitleStyle = lipgloss.NewStyle().
-		Foreground(TextMuted).
-		Italic(true)
-	
+			Foreground(TextMuted).
+			Italic(true)
+
 	BodyStyle = lipgloss.NewStyle().
-		Foreground(Text)
-	
+			Foreground(Text)
+
 	// Status styles
 	SuccessStyle = lipgloss.NewStyle().
-		Foreground(Success).
-		Bold(true)
-	
+			Foreground(Success).
+			Bold(true)
+
 	ErrorStyle = lipgloss.NewStyle().
-		Foreground(Error).
-		Bold(true)
-	
+			Foreground(Error).
+			Bold(true)
+
 	WarningStyle = lipgloss.NewStyle().
-		Foreground(Warning).
-		Bold(true)
-	
+			Foreground(Warning).
+			Bold(true)
+
 	InfoStyle = lipgloss.NewStyle().
-		Foreground(Secondary).
-		Bold(true)
-	
+			Foreground(Secondary).
+			Bold(true)
+
 	// Container styles
 	BoxStyle = lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(Border).
-		Padding(1, 2).
-		MarginBottom(1)
-	
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(Border).
+			Padding(1, 2).
+			MarginBottom(1)
+
 	HighlightBoxStyle = lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(Primary).
-		Padding(1, 2).
-		MarginBottom(1)
-	
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(Primary).
+				Padding(1, 2).
+				MarginBottom(1)
+
 	// List styles
 	ListItemStyle = lipgloss.NewStyle().
-		PaddingLeft(2).
-		MarginBottom(0)
-	
+			PaddingLeft(2).
+			MarginBottom(0)
+
 	ListNumberStyle = lipgloss.NewStyle().
-		Foreground(Primary).
-		Bold(true).
-		Width(4).
-		Align(lipgloss.Right)
-	
+			Foreground(Primary).
+			Bold(true).
+			Width(4).
+			Align(lipgloss.Right)
+
 	// Tag styles
 	TagStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(Secondary).
-		Padding(0, 1).
-		MarginRight(1).
-		Bold(true)
-	
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(Secondary).
+			Padding(0, 1).
+			MarginRight(1).
+			Bold(true)
+
 	// Code styles
 	CodeStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#E11D48")).
-		Background(Surface).
-		Padding(0, 1)
-	
+			Foreground(lipgloss.Color("#E11D48")).
+			Background(Surface).
+			Padding(0, 1)
+
 	CodeBlockStyle = lipgloss.NewStyle().
-		Background(Surface).
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(Border).
-		Padding(1).
-		MarginTop(1).
-		MarginBottom(1)
+			Background(Surface).
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(Border).
+			Padding(1).
+			MarginTop(1).
+			MarginBottom(1)
 )
 
 // Icon constants
 const (
-	IconSuccess   = "✅"
-	IconError     = "❌"
-	IconWarning   = "⚠️"
-	IconInfo      = "ℹ️"
-	IconSnippet   = "📝"
-	IconSearch    = "🔍"
-	IconList      = "📚"
-	IconCopy      = "📋"
-	IconEdit      = "✏️"
-	IconDelete    = "🗑️"
-	IconTag       = "🏷️"
-	IconTime      = "⏰"
-	IconFolder    = "📁"
-	IconDatabase  = "💾"
-	IconRocket    = "🚀"
-	IconSparkles  = "✨"
+	IconSuccess  = "✅"
+	IconError    = "❌"
+	IconWarning  = "⚠️"
+	IconInfo     = "ℹ️"
+	IconSnippet  = "📝"
+	IconSearch   = "🔍"
+	IconList     = "📚"
+	IconCopy     = "📋"
+	IconEdit     = "✏️"
+	IconDelete   = "🗑️"
+	IconTag      = "🏷️"
+	IconTime     = "⏰"
+	IconFolder   = "📁"
+	IconDatabase = "💾"
+	IconRocket   = "🚀"
+	IconSparkles = "✨"
+	IconLock     = "🔒"
+	IconLink     = "🔗"
+	IconLanguage = "💻"
+	IconPin      = "📌"
 )
 
 // Helper functions for common UI patterns
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,45 +14,100 @@ import (
 // Table styles
 var (
 	headerStyle = lipgloss.NewStyle().
-		Foreground(Primary).
-		Bold(true).
-		Align(lipgloss.Center).
-		Padding(0, 1)
-	
+			Foreground(Primary).
+			Bold(true).
+			Align(lipgloss.Center).
+			Padding(0, 1)
+
 	cellStyle = lipgloss.NewStyle().
-		Padding(0, 1).
-		Align(lipgloss.Left)
-	
+			Padding(0, 1).
+			Align(lipgloss.Left)
+
 	idCellStyle = lipgloss.NewStyle().
-		Foreground(Primary).
-		Bold(true).
-		Padding(0, 1).
-		Align(lipgloss.Center).
-		Width(4)
-	
+			Foreground(Primary).
+			Bold(true).
+			Padding(0, 1).
+			Align(lipgloss.Center).
+			Width(4)
+
 	titleCellStyle = lipgloss.NewStyle().
-		Foreground(Text).
-		Bold(true).
-		Padding(0, 1).
-		Width(30)
-	
+			Foreground(Text).
+			Bold(true).
+			Padding(0, 1).
+			Width(30)
+
 	tagsCellStyle = lipgloss.NewStyle().
-		Foreground(TextMuted).
-		Padding(0, 1).
-		Width(25)
-	
+			Foreground(TextMuted).
+			Padding(0, 1).
+			Width(25)
+
 	timeCellStyle = lipgloss.NewStyle().
-		Foreground(TextMuted).
-		Padding(0, 1).
-		Width(15)
+			Foreground(TextMuted).
+			Padding(0, 1).
+			Width(15)
+
+	sectionStyle = lipgloss.NewStyle().
+			Foreground(Secondary).
+			Bold(true)
+
+	// Characters matched by a fuzzy search
+	matchStyle = lipgloss.NewStyle().
+			Foreground(Warning).
+			Bold(true).
+			Underline(true)
+
+	// CodeStyle without its padding, for previews built from several pieces
+	previewStyle = CodeStyle.UnsetPadding()
+)
+
+// TimeColumn selects which timestamp the last column of RenderSnippetsTable shows
+type TimeColumn int
+
+const (
+	ColumnCreated TimeColumn = iota
+	ColumnUpdated
+	ColumnLastUsed
 )
 
-// RenderSnippetsTable creates a beautiful table for displaying snippets
-func RenderSnippetsTable(snippets []models.Snippet) string {
+// RenderSnippetsTable creates a beautiful table for displaying snippets.
+// Pinned snippets get a section of their own above the rest.
+func RenderSnippetsTable(snippets []models.Snippet, column TimeColumn) string {
 	if len(snippets) == 0 {
 		return RenderInfo("No snippets found. Use 'snip save' to create your first snippet!")
 	}
 
+	pinned, others := splitPinned(snippets)
+	if len(pinned) == 0 || len(others) == 0 {
+		return renderSnippetsTable(snippets, column)
+	}
+	return sectionStyle.Render(IconPin+" Pinned") + "\n" + renderSnippetsTable(pinned, column) + "\n\n" +
+		sectionStyle.Render("Other snippets") + "\n" + renderSnippetsTable(others, column)
+}
+
+// splitPinned separates pinned snippets from the rest, keeping their order
+func splitPinned(snippets []models.Snippet) (pinned, others []models.Snippet) {
+	for _, snippet := range snippets {
+		if snippet.Pinned {
+			pinned = append(pinned, snippet)
+		} else {
+			others = append(others, snippet)
+		}
+	}
+	return pinned, others
+}
+
+func renderSnippetsTable(snippets []models.Snippet, column TimeColumn) string {
+	// Results drawn from several libraries say where each snippet lives
+	headers := []string{"ID", "Title", "Tags", column.header()}
+	showLibrary := false
+	for _, snippet := range snippets {
+		if snippet.Library != "" {
+			showLibrary = true
+			headers = append(headers, "Library")
+			break
+		}
+	}
+
 	t := table.New().
 		Border(lipgloss.RoundedBorder()).
 		BorderStyle(lipgloss.NewStyle().Foreground(Border)).
@@ -65,13 +121,15 @@ func RenderSnippetsTable(snippets []models.Snippet) string {
 				return titleCellStyle
 			case col == 2: // Tags column
 				return tagsCellStyle
+			case col == 3 && column == ColumnLastUsed: // Time column with use count
+				return timeCellStyle.Width(20)
 			case col == 3: // Time column
 				return timeCellStyle
 			default:
 				return cellStyle
 			}
 		}).
-		Headers("ID", "Title", "Tags", "Created")
+		Headers(headers...)
 
 	// Add rows
 	for _, snippet := range snippets {
@@ -88,19 +146,138 @@ func RenderSnippetsTable(snippets []models.Snippet) string {
 		}
 
 		// Format time
-		timeStr := formatTimeAgo(snippet.CreatedAt)
+		timeStr := column.value(snippet)
 
 		// Truncate title if too long
 		title := snippet.Title
 		if len(title) > 28 {
 			title = title[:25] + "..."
 		}
+		if len(snippet.Files) > 0 {
+			title = IconFolder + " " + title
+		}
+		if snippet.Encrypted {
+			title = IconLock + " " + title
+		}
 
-		t.Row(
+		row := []string{
 			fmt.Sprintf("%d", snippet.ID),
 			title,
 			tagsStr,
 			timeStr,
+		}
+		if showLibrary {
+			row = append(row, snippet.Library)
+		}
+		t.Row(row...)
+	}
+
+	return t.Render()
+}
+
+// RenderTrashTable creates a table of trashed snippets with when they were deleted
+func RenderTrashTable(snippets []models.Snippet) string {
+	if len(snippets) == 0 {
+		return RenderInfo("The trash is empty.")
+	}
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(Border)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return headerStyle
+			case col == 0: // ID column
+				return idCellStyle
+			case col == 1: // Title column
+				return titleCellStyle
+			case col == 2: // Tags column
+				return tagsCellStyle
+			case col == 3: // Time column
+				return timeCellStyle
+			default:
+				return cellStyle
+			}
+		}).
+		Headers("ID", "Title", "Tags", "Deleted")
+
+	for _, snippet := range snippets {
+		tagsStr := ""
+		if len(snippet.Tags) > 0 {
+			var formattedTags []string
+			for _, tag := range snippet.Tags {
+				formattedTags = append(formattedTags, RenderTag(tag))
+			}
+			tagsStr = strings.Join(formattedTags, " ")
+		} else {
+			tagsStr = lipgloss.NewStyle().Foreground(TextMuted).Render("no tags")
+		}
+
+		title := snippet.Title
+		if len(title) > 28 {
+			title = title[:25] + "..."
+		}
+
+		t.Row(
+			fmt.Sprintf("%d", snippet.ID),
+			title,
+			tagsStr,
+			FormatTimeAgo(snippet.DeletedAt),
+		)
+	}
+
+	return t.Render()
+}
+
+// RenderRevisionsTable creates a table of a snippet's saved revisions, oldest
+// first. Line counts are hidden for encrypted snippets.
+func RenderRevisionsTable(revisions []models.Revision, encrypted bool) string {
+	if len(revisions) == 0 {
+		return RenderInfo("No revisions recorded for this snippet.")
+	}
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(Border)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return headerStyle
+			case col == 0: // Revision column
+				return idCellStyle.Width(5)
+			case col == 1: // Title column
+				return titleCellStyle
+			case col == 3: // Time column
+				return timeCellStyle
+			default:
+				return cellStyle
+			}
+		}).
+		Headers("Rev", "Title", "Lines", "Saved")
+
+	for i, revision := range revisions {
+		title := revision.Title
+		if len(title) > 28 {
+			title = title[:25] + "..."
+		}
+
+		lines := fmt.Sprintf("%d", strings.Count(strings.TrimRight(revision.Content, "\n"), "\n")+1)
+		if len(revision.Files) > 0 {
+			lines = fmt.Sprintf("%d file(s)", len(revision.Files))
+		}
+		if encrypted {
+			lines = IconLock
+		}
+		if i == len(revisions)-1 {
+			lines += " (current)"
+		}
+
+		t.Row(
+			fmt.Sprintf("%d", revision.Number),
+			title,
+			lines,
+			FormatTimeAgo(revision.CreatedAt),
 		)
 	}
 
@@ -110,12 +287,27 @@ func RenderSnippetsTable(snippets []models.Snippet) string {
 // RenderSnippetCard creates a detailed card view for a single snippet
 func RenderSnippetCard(snippet models.Snippet, showContent bool) string {
 	var content strings.Builder
-	
+
 	// Header with ID and title
 	header := fmt.Sprintf("%s %d: %s", IconSnippet, snippet.ID, snippet.Title)
 	content.WriteString(TitleStyle.Render(header))
 	content.WriteString("\n")
-	
+
+	if snippet.Description != "" {
+		content.WriteString(BodyStyle.Render(snippet.Description))
+		content.WriteString("\n")
+	}
+
+	// Identifiers that stay the same across machines
+	if snippet.Slug != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLink + " Slug: " + snippet.Slug))
+		content.WriteString("\n")
+	}
+	if snippet.UUID != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLink + " UUID: " + snippet.UUID))
+		content.WriteString("\n")
+	}
+
 	// Tags
 	if len(snippet.Tags) > 0 {
 		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconTag + " Tags: "))
@@ -128,38 +320,110 @@ func RenderSnippetCard(snippet models.Snippet, showContent bool) string {
 		content.WriteString("\n")
 	}
 
+	if snippet.Collection != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconFolder + " Collection: " + snippet.Collection))
+		content.WriteString("\n")
+	}
+	if snippet.Language != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLanguage + " Language: " + snippet.Language))
+		content.WriteString("\n")
+	}
+	if snippet.SourceURL != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLink + " Source: " + snippet.SourceURL))
+		content.WriteString("\n")
+	}
+
 	// Created time
-	timeStr := formatTimeAgo(snippet.CreatedAt)
+	timeStr := FormatTimeAgo(snippet.CreatedAt)
 	content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconTime + " Created: " + timeStr))
 	content.WriteString("\n")
 
-	// Content if requested
-	if showContent {
+	// Updated time, only when the snippet has actually been changed
+	if snippet.UpdatedAt.Sub(snippet.CreatedAt) >= time.Second {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconEdit + " Updated: " + FormatTimeAgo(snippet.UpdatedAt)))
+		content.WriteString("\n")
+	}
+
+	// Usage
+	content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconCopy + " Used: " + formatUsage(snippet)))
+	content.WriteString("\n")
+
+	if len(snippet.Meta) > 0 {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconInfo + " Meta: " + FormatMeta(snippet.Meta)))
+		content.WriteString("\n")
+	}
+
+	if snippet.Pinned {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconPin + " Pinned"))
+		content.WriteString("\n")
+	}
+
+	if snippet.Encrypted {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLock + " Encrypted"))
+		content.WriteString("\n")
+	}
+
+	// Files of a multi-file snippet
+	if len(snippet.Files) > 0 {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconFolder + " Files: " + fileNames(snippet.Files)))
+		content.WriteString("\n")
+	}
+
+	// Related snippets, linked from either side
+	if related := relatedSnippets(snippet); len(related) > 0 {
+		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(IconLink + " Related:"))
+		content.WriteString("\n")
+		for _, line := range related {
+			content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render("   " + line))
+			content.WriteString("\n")
+		}
+	}
+
+	// Content if requested; encrypted content is never shown here
+	if showContent && !snippet.Encrypted {
 		content.WriteString("\n")
 		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render("Content:"))
 		content.WriteString("\n")
-		content.WriteString(CodeBlockStyle.Render(snippet.Content))
+		if len(snippet.Files) > 0 {
+			for _, file := range snippet.Files {
+				content.WriteString(BodyStyle.Bold(true).Render(file.Name))
+				content.WriteString("\n")
+				content.WriteString(CodeBlockStyle.Render(file.Content))
+				content.WriteString("\n")
+			}
+		} else {
+			content.WriteString(CodeBlockStyle.Render(snippet.Content))
+		}
 	}
-	
+
 	return HighlightBoxStyle.Render(content.String())
 }
 
-// RenderSearchResults creates a formatted display for search results
-func RenderSearchResults(snippets []models.Snippet, query string, tagFilter string) string {
+// Highlight marks the runes a fuzzy search matched in a result, by index in
+// its title, its tags joined by spaces, and its content
+type Highlight struct {
+	Title   []int
+	Tags    []int
+	Content []int
+}
+
+// RenderSearchResults creates a formatted display for search results.
+// highlights, when given, holds the matched runes of each result, in order.
+func RenderSearchResults(snippets []models.Snippet, query string, tagFilter string, highlights []Highlight) string {
 	var content strings.Builder
-	
+
 	// Header
 	if tagFilter != "" {
-		header := fmt.Sprintf("%s Found %d snippet(s) matching '%s' with tag '%s':", 
+		header := fmt.Sprintf("%s Found %d snippet(s) matching '%s' with tag '%s':",
 			IconSearch, len(snippets), query, tagFilter)
 		content.WriteString(InfoStyle.Render(header))
 	} else {
-		header := fmt.Sprintf("%s Found %d snippet(s) matching '%s':", 
+		header := fmt.Sprintf("%s Found %d snippet(s) matching '%s':",
 			IconSearch, len(snippets), query)
 		content.WriteString(InfoStyle.Render(header))
 	}
 	content.WriteString("\n\n")
-	
+
 	if len(snippets) == 0 {
 		if tagFilter != "" {
 			content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render(fmt.Sprintf("No snippets found matching '%s' with tag '%s'", query, tagFilter)))
@@ -168,29 +432,58 @@ func RenderSearchResults(snippets []models.Snippet, query string, tagFilter stri
 		}
 		return content.String()
 	}
-	
-	// Results
+
+	// Results, with pinned snippets in a section of their own
+	var pinned, others []int
 	for i, snippet := range snippets {
+		if snippet.Pinned {
+			pinned = append(pinned, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	sections := len(pinned) > 0 && len(others) > 0
+	for i, index := range slices.Concat(pinned, others) {
+		snippet := snippets[index]
+		var highlight Highlight
+		if index < len(highlights) {
+			highlight = highlights[index]
+		}
+
 		if i > 0 {
 			content.WriteString("\n")
 		}
-		
+		if sections && i == 0 {
+			content.WriteString(sectionStyle.Render(IconPin + " Pinned"))
+			content.WriteString("\n\n")
+		}
+		if sections && i == len(pinned) {
+			content.WriteString(sectionStyle.Render("Other results"))
+			content.WriteString("\n\n")
+		}
+
 		// Snippet header
-		header := fmt.Sprintf("%d. %s", snippet.ID, snippet.Title)
-		content.WriteString(BodyStyle.Bold(true).Render(header))
-		
+		content.WriteString(BodyStyle.Bold(true).Render(fmt.Sprintf("%d. ", snippet.ID)))
+		content.WriteString(renderHighlighted([]rune(snippet.Title), highlight.Title, BodyStyle.Bold(true)))
+
 		// Tags
 		if len(snippet.Tags) > 0 {
 			content.WriteString(" ")
+			offset := 0
 			for _, tag := range snippet.Tags {
-				content.WriteString(RenderTag(tag))
+				runes := []rune(tag)
+				content.WriteString(highlightedTag(runes, within(highlight.Tags, offset, offset+len(runes))))
 				content.WriteString(" ")
+				offset += len(runes) + 1
 			}
 		}
 		content.WriteString("\n")
-		
-		// Time
-		timeStr := formatTimeAgo(snippet.CreatedAt)
+
+		// Time, and the library when results span several
+		timeStr := FormatTimeAgo(snippet.CreatedAt)
+		if snippet.Library != "" {
+			timeStr += " in library " + snippet.Library
+		}
 		content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render("     Created " + timeStr))
 		content.WriteString("\n")
 
@@ -199,42 +492,184 @@ func RenderSearchResults(snippets []models.Snippet, query string, tagFilter stri
 		if len(preview) > 80 {
 			preview = preview[:77] + "..."
 		}
+		if len(snippet.Files) > 0 {
+			preview = IconFolder + " " + fileNames(snippet.Files)
+		}
+		if snippet.Encrypted {
+			preview = IconLock + " encrypted"
+		}
 		if preview != "" {
 			content.WriteString(lipgloss.NewStyle().Foreground(TextMuted).Render("     Preview: "))
-			content.WriteString(CodeStyle.Render(preview))
+			if len(highlight.Content) > 0 && len(snippet.Files) == 0 && !snippet.Encrypted {
+				content.WriteString(highlightedPreview(snippet.Content, highlight.Content))
+			} else {
+				content.WriteString(CodeStyle.Render(preview))
+			}
 		}
 		content.WriteString("\n")
 	}
-	
+
 	return content.String()
 }
 
-// formatTimeAgo formats a time as a human-readable "time ago" string
-func formatTimeAgo(t time.Time) string {
-	now := time.Now()
-	diff := now.Sub(t)
-	
-	if diff < time.Minute {
-		return "just now"
-	} else if diff < time.Hour {
-		minutes := int(diff.Minutes())
-		if minutes == 1 {
-			return "1 minute ago"
-		}
-		return fmt.Sprintf("%d minutes ago", minutes)
-	} else if diff < 24*time.Hour {
-		hours := int(diff.Hours())
-		if hours == 1 {
-			return "1 hour ago"
-		}
-		return fmt.Sprintf("%d hours ago", hours)
-	} else if diff < 7*24*time.Hour {
-		days := int(diff.Hours() / 24)
-		if days == 1 {
-			return "1 day ago"
-		}
-		return fmt.Sprintf("%d days ago", days)
-	} else {
-		return t.Format("Jan 2, 2006")
+// highlightedPreview renders up to 77 runes of content around its first
+// highlighted rune, with the highlighted runes picked out
+func highlightedPreview(content string, positions []int) string {
+	runes := []rune(content)
+	for i, r := range runes {
+		if r == '\n' || r == '\t' || r == '\r' {
+			runes[i] = ' '
+		}
+	}
+
+	start := max(0, positions[0]-20)
+	end := min(len(runes), start+77)
+	shifted := within(positions, start, end)
+
+	var preview strings.Builder
+	preview.WriteString(previewStyle.Render(" "))
+	if start > 0 {
+		preview.WriteString(previewStyle.Render("..."))
+	}
+	preview.WriteString(renderHighlighted(runes[start:end], shifted, previewStyle))
+	if end < len(runes) {
+		preview.WriteString(previewStyle.Render("..."))
+	}
+	preview.WriteString(previewStyle.Render(" "))
+	return preview.String()
+}
+
+// highlightedTag renders a tag like RenderTag, with the runes at positions
+// picked out
+func highlightedTag(tag []rune, positions []int) string {
+	if len(positions) == 0 {
+		return RenderTag(string(tag))
+	}
+	// The padding goes around the whole tag rather than each piece of it
+	style := TagStyle.UnsetPadding().UnsetMargins()
+	return style.Render(" ") + renderHighlighted(tag, positions, style) + style.Render(" ") + " "
+}
+
+// within returns the positions in [start, end), made relative to start
+func within(positions []int, start, end int) []int {
+	var shifted []int
+	for _, position := range positions {
+		if position >= start && position < end {
+			shifted = append(shifted, position-start)
+		}
+	}
+	return shifted
+}
+
+// renderHighlighted renders text in style, with the runes at positions
+// picked out in matchStyle
+func renderHighlighted(text []rune, positions []int, style lipgloss.Style) string {
+	matched := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		matched[position] = true
+	}
+	highlight := matchStyle.Inherit(style)
+
+	var result strings.Builder
+	for i := 0; i < len(text); {
+		j := i + 1
+		for j < len(text) && matched[j] == matched[i] {
+			j++
+		}
+		if matched[i] {
+			result.WriteString(highlight.Render(string(text[i:j])))
+		} else {
+			result.WriteString(style.Render(string(text[i:j])))
+		}
+		i = j
+	}
+	return result.String()
+}
+
+// FormatMeta renders metadata as "key=value" pairs in key order
+func FormatMeta(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+meta[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// relatedSnippets describes a snippet's links and backlinks, one per line,
+// mentioning each related snippet once per relation
+func relatedSnippets(snippet models.Snippet) []string {
+	var lines []string
+	seen := make(map[string]bool)
+	add := func(relation string, link models.Link) {
+		line := fmt.Sprintf("%s %d: %s", relation, link.ID, link.Title)
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+
+	for _, link := range snippet.Links {
+		add(strings.ReplaceAll(link.Rel, "-", " "), link)
+	}
+	for _, link := range snippet.Backlinks {
+		if link.Rel == "depends-on" {
+			add("needed by", link)
+		} else {
+			add(strings.ReplaceAll(link.Rel, "-", " "), link)
+		}
+	}
+	return lines
+}
+
+// header returns the table header for a time column
+func (c TimeColumn) header() string {
+	switch c {
+	case ColumnUpdated:
+		return "Updated"
+	case ColumnLastUsed:
+		return "Last Used (Uses)"
+	default:
+		return "Created"
+	}
+}
+
+// value formats a snippet's timestamp for a time column
+func (c TimeColumn) value(snippet models.Snippet) string {
+	switch c {
+	case ColumnUpdated:
+		return FormatTimeAgo(snippet.UpdatedAt)
+	case ColumnLastUsed:
+		if snippet.LastUsedAt.IsZero() {
+			return "never"
+		}
+		return fmt.Sprintf("%s (%d)", FormatTimeAgo(snippet.LastUsedAt), snippet.UseCount)
+	default:
+		return FormatTimeAgo(snippet.CreatedAt)
+	}
+}
+
+// formatUsage describes how often and how recently a snippet was used
+func formatUsage(snippet models.Snippet) string {
+	if snippet.UseCount == 0 || snippet.LastUsedAt.IsZero() {
+		return "never"
+	}
+	if snippet.UseCount == 1 {
+		return "once, " + FormatTimeAgo(snippet.LastUsedAt)
+	}
+	return fmt.Sprintf("%d times, last %s", snippet.UseCount, FormatTimeAgo(snippet.LastUsedAt))
+}
+
+// fileNames lists the files of a multi-file snippet
+func fileNames(files []models.File) string {
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
 	}
+	return strings.Join(names, ", ")
 }
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	// Bundle the zone database so SetTimezone works on systems without one
+	_ "time/tzdata"
+)
+
+// location is the time zone timestamps are displayed in
+var location = time.Local
+
+// SetTimezone displays timestamps in the named IANA time zone, such as
+// "Europe/Berlin", instead of the system's local zone
+func SetTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	location = loc
+	return nil
+}
+
+// LocalTime converts t to the display time zone
+func LocalTime(t time.Time) time.Time {
+	return t.In(location)
+}
+
+// FormatTimeAgo formats a time as a human-readable "time ago" string
+func FormatTimeAgo(t time.Time) string {
+	now := time.Now()
+	diff := now.Sub(t)
+
+	if diff < time.Minute {
+		return "just now"
+	} else if diff < time.Hour {
+		minutes := int(diff.Minutes())
+		if minutes == 1 {
+			return "1 minute ago"
+		}
+		return fmt.Sprintf("%d minutes ago", minutes)
+	} else if diff < 24*time.Hour {
+		hours := int(diff.Hours())
+		if hours == 1 {
+			return "1 hour ago"
+		}
+		return fmt.Sprintf("%d hours ago", hours)
+	} else if diff < 7*24*time.Hour {
+		days := int(diff.Hours() / 24)
+		if days == 1 {
+			return "1 day ago"
+		}
+		return fmt.Sprintf("%d days ago", days)
+	} else {
+		return LocalTime(t).Format("Jan 2, 2006")
+	}
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/tree"
+)
+
+// Collection tree styles
+var (
+	treeEnumeratorStyle = lipgloss.NewStyle().
+				Foreground(Border).
+				MarginRight(1)
+
+	treeNameStyle = lipgloss.NewStyle().
+			Foreground(Text).
+			Bold(true)
+
+	treeCountStyle = lipgloss.NewStyle().
+			Foreground(TextMuted)
+)
+
+// collectionNode is one collection in the tree, with the snippets filed
+// directly in it and in everything nested below it
+type collectionNode struct {
+	name     string
+	direct   int
+	total    int
+	children map[string]*collectionNode
+}
+
+// RenderCollectionTree draws collections as a tree. counts maps each
+// collection path, like "infra/k8s", to the number of snippets filed directly
+// in it; "" counts the unfiled snippets.
+func RenderCollectionTree(counts map[string]int) string {
+	root := &collectionNode{children: map[string]*collectionNode{}}
+	for path, count := range counts {
+		root.total += count
+		if path == "" {
+			root.direct += count
+			continue
+		}
+		node := root
+		for _, name := range strings.Split(path, "/") {
+			child, ok := node.children[name]
+			if !ok {
+				child = &collectionNode{name: name, children: map[string]*collectionNode{}}
+				node.children[name] = child
+			}
+			child.total += count
+			node = child
+		}
+		node.direct += count
+	}
+
+	if len(root.children) == 0 {
+		if root.total == 0 {
+			return RenderInfo("No snippets found. Use 'snip save' to create your first snippet!")
+		}
+		return RenderInfo("No collections yet. File snippets with 'snip save --in <collection>' or 'snip mv'.")
+	}
+
+	t := tree.Root(treeNameStyle.Render(IconFolder+" Library") + " " + treeCountStyle.Render(countLabel(root.total))).
+		Enumerator(tree.RoundedEnumerator).
+		EnumeratorStyle(treeEnumeratorStyle)
+	for _, child := range root.sortedChildren() {
+		t.Child(child.tree())
+	}
+	if root.direct > 0 {
+		t.Child(treeCountStyle.Render("(no collection) " + countLabel(root.direct)))
+	}
+	return t.String()
+}
+
+// tree renders a collection and everything nested in it
+func (node *collectionNode) tree() any {
+	label := treeNameStyle.Render(node.name) + " " + treeCountStyle.Render(node.countLabel())
+	if len(node.children) == 0 {
+		return label
+	}
+
+	t := tree.Root(label).
+		Enumerator(tree.RoundedEnumerator).
+		EnumeratorStyle(treeEnumeratorStyle)
+	for _, child := range node.sortedChildren() {
+		t.Child(child.tree())
+	}
+	return t
+}
+
+// countLabel counts a collection's snippets, noting how many of them are
+// filed in nested collections
+func (node *collectionNode) countLabel() string {
+	if node.direct == node.total {
+		return countLabel(node.total)
+	}
+	return fmt.Sprintf("(%s, %d here)", snippetCount(node.total), node.direct)
+}
+
+func (node *collectionNode) sortedChildren() []*collectionNode {
+	children := make([]*collectionNode, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return strings.ToLower(children[i].name) < strings.ToLower(children[j].name)
+	})
+	return children
+}
+
+func countLabel(count int) string {
+	return "(" + snippetCount(count) + ")"
+}
+
+func snippetCount(count int) string {
+	if count == 1 {
+		return "1 snippet"
+	}
+	return fmt.Sprintf("%d snippets", count)
+}